@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/holgerfy/go-pkg/app"
+	"github.com/holgerfy/go-pkg/config"
+	"github.com/holgerfy/go-pkg/health"
+	"github.com/holgerfy/go-pkg/log"
+)
+
+const SecretHeader = "X-Admin-Secret"
+
+var (
+	server *http.Server
+	conf   struct {
+		Enable bool   `toml:"enable"`
+		Addr   string `toml:"addr"`
+		Secret string `toml:"secret"`
+		Pprof  *bool  `toml:"pprof"` // defaults to the DebugEndpoints of the environment profile
+	}
+	ErrNoSecret = errors.New("admin secret is not configured")
+	ErrNoAddr   = errors.New("admin addr is not configured")
+)
+
+func init() {
+	config.RegisterTarget("admin", "", &conf)
+}
+
+// Start serves the admin endpoints if the [admin] node enables them.
+func Start() error {
+	err := config.GetInstance().Bind("admin", "", &conf)
+	if err == config.ErrNodeNotExists {
+		return nil
+	}
+	if err != nil || !conf.Enable {
+		return err
+	}
+	if conf.Addr == "" {
+		return ErrNoAddr
+	}
+	if conf.Secret == "" {
+		return ErrNoSecret
+	}
+	listener, err := net.Listen("tcp", conf.Addr)
+	if err != nil {
+		return err
+	}
+	server = &http.Server{Handler: Handler()}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Logger().Error(context.Background(), "admin server stopped, err: ", err)
+		}
+	}()
+	log.Logger().Info(context.Background(), "admin server listening on ", listener.Addr())
+	return nil
+}
+
+func Stop(ctx context.Context) error {
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+func Component() *app.Component {
+	return &app.Component{
+		Name:      "admin",
+		DependsOn: []string{"config", "log"},
+		Start: func(ctx context.Context) error {
+			return Start()
+		},
+		Stop: Stop,
+	}
+}
+
+// Handler returns the admin endpoints behind the shared-secret check.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	pprofEnabled := app.CurrentProfile().DebugEndpoints
+	if conf.Pprof != nil {
+		pprofEnabled = *conf.Pprof
+	}
+	if pprofEnabled {
+		mux.HandleFunc("/debug/pprof/", pprofIndex)
+		mux.HandleFunc("/debug/pprof/cmdline", pprofCmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprofProfile)
+		mux.HandleFunc("/debug/pprof/trace", pprofTrace)
+	}
+	mux.HandleFunc("/debug/vars", vars)
+	mux.Handle("/services", jsonHandler(func() interface{} {
+		return app.ServiceNames()
+	}))
+	mux.Handle("/config", jsonHandler(func() interface{} {
+		return config.GetInstance().Redacted()
+	}))
+	mux.Handle("/loglevel", log.LevelHandler())
+	mux.Handle("/version", app.VersionHandler())
+	health.RegisterHTTP(mux)
+	return authorize(mux)
+}
+
+func authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(SecretHeader)
+		if conf.Secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(conf.Secret)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func jsonHandler(f func() interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(f())
+	})
+}
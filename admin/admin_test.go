@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/holgerfy/go-pkg/config"
+)
+
+func loadConfig(t *testing.T, content string) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "admin.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := config.Options{Paths: []string{dir}, Environ: []string{"ADMIN_SECRET=admin-pass"}, Args: []string{}}
+	if err := config.LoadInstance(opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(h http.Handler, path, secret string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if secret != "" {
+		req.Header.Set(SecretHeader, secret)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStartRequiresAddr(t *testing.T) {
+	loadConfig(t, "enable = true\nsecret = \"${env:ADMIN_SECRET}\"\n")
+	if err := Start(); !errors.Is(err, ErrNoAddr) {
+		t.Fatalf("start = %v, want %v", err, ErrNoAddr)
+	}
+}
+
+func TestDisabledWithoutAddr(t *testing.T) {
+	loadConfig(t, "enable = false\n")
+	if err := Start(); err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range config.GetInstance().Check() {
+		if res.Path == "admin" && res.Err != nil {
+			t.Fatalf("check admin = %v", res.Err)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	loadConfig(t, "enable = true\naddr = \"127.0.0.1:0\"\nsecret = \"${env:ADMIN_SECRET}\"\npprof = false\n")
+	if err := config.GetInstance().Bind("admin", "", &conf); err != nil {
+		t.Fatal(err)
+	}
+	h := Handler()
+	for _, secret := range []string{"", "wrong"} {
+		if rec := get(h, "/services", secret); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("secret %q: got %d, want 401", secret, rec.Code)
+		}
+	}
+	if rec := get(h, "/services", "admin-pass"); rec.Code != http.StatusOK {
+		t.Fatalf("services returned %d", rec.Code)
+	}
+	if rec := get(h, "/debug/pprof/", "admin-pass"); rec.Code != http.StatusNotFound {
+		t.Fatalf("pprof is disabled but returned %d", rec.Code)
+	}
+
+	rec := get(h, "/config", "admin-pass")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "admin-pass") || !strings.Contains(rec.Body.String(), "127.0.0.1:0") {
+		t.Fatalf("config returned %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := get(h, "/debug/vars", "admin-pass"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "memstats") {
+		t.Fatalf("vars returned %d %s", rec.Code, rec.Body)
+	}
+
+	pprof := true
+	conf.Pprof = &pprof
+	h = Handler()
+	if rec := get(h, "/debug/pprof/", "admin-pass"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "goroutine") {
+		t.Fatalf("pprof is enabled but returned %d", rec.Code)
+	}
+	if rec := get(h, "/debug/pprof/heap?debug=1", "admin-pass"); rec.Code != http.StatusOK {
+		t.Fatalf("heap profile returned %d", rec.Code)
+	}
+
+	// nothing is registered on the default mux, outside the secret check
+	for _, path := range []string{"/debug/pprof/", "/debug/vars"} {
+		if rec := get(http.DefaultServeMux, path, ""); rec.Code != http.StatusNotFound {
+			t.Fatalf("default mux serves %s with %d", path, rec.Code)
+		}
+	}
+}
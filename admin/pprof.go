@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The handlers below are written on runtime/pprof rather than imported from net/http/pprof and
+// expvar, whose init registers them on http.DefaultServeMux, outside the secret check.
+
+// pprofIndex lists the profiles at /debug/pprof/ and writes /debug/pprof/<name>, ?debug=1 as text.
+func pprofIndex(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+	if name == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		profiles := pprof.Profiles()
+		sort.Slice(profiles, func(i, j int) bool {
+			return profiles[i].Name() < profiles[j].Name()
+		})
+		for _, p := range profiles {
+			fmt.Fprintf(w, "%d\t%s\n", p.Count(), p.Name())
+		}
+		fmt.Fprintln(w, "-\tprofile")
+		fmt.Fprintln(w, "-\ttrace")
+		return
+	}
+	p := pprof.Lookup(name)
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+	debug, _ := strconv.Atoi(r.FormValue("debug"))
+	if debug > 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	}
+	p.WriteTo(w, debug)
+}
+
+func pprofCmdline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, strings.Join(os.Args, "\x00"))
+}
+
+// pprofProfile records the CPU for ?seconds=, 30 by default.
+func pprofProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := pprof.StartCPUProfile(w); err != nil {
+		http.Error(w, "could not enable CPU profiling: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sleep(r, 30*time.Second)
+	pprof.StopCPUProfile()
+}
+
+// pprofTrace records an execution trace for ?seconds=, 1 by default.
+func pprofTrace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := trace.Start(w); err != nil {
+		http.Error(w, "could not enable tracing: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sleep(r, time.Second)
+	trace.Stop()
+}
+
+func sleep(r *http.Request, def time.Duration) {
+	d := def
+	if sec, err := strconv.ParseFloat(r.FormValue("seconds"), 64); err == nil && sec > 0 {
+		d = time.Duration(sec * float64(time.Second))
+	}
+	select {
+	case <-time.After(d):
+	case <-r.Context().Done():
+	}
+}
+
+// vars serves what expvar would: the command line and the memory stats.
+func vars(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"cmdline": os.Args, "memstats": mem})
+}
@@ -1,8 +1,11 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"os"
-	"path/filepath"
+	"reflect"
+	"sort"
 	"sync"
 )
 
@@ -13,13 +16,34 @@ const (
 	EnvModelLocal   = "local"
 )
 
-var services = &Services{services: make(map[string]interface{})}
+var (
+	services         = &Services{services: make(map[string]interface{})}
+	ErrNotRegistered = errors.New("service not registered")
+	ErrRegistered    = errors.New("service already registered")
+	ErrTypeMismatch  = errors.New("service type mismatch")
+)
 
 type Services struct {
 	lock     sync.Mutex
 	services map[string]interface{}
 }
 
+// provider is stored in the services map for lazily constructed services,
+// the factory runs once on first resolve.
+type provider struct {
+	once    sync.Once
+	factory func() (interface{}, error)
+	val     interface{}
+	err     error
+}
+
+func (p *provider) resolve() (interface{}, error) {
+	p.once.Do(func() {
+		p.val, p.err = p.factory()
+	})
+	return p.val, p.err
+}
+
 func (service *Services) register(name string, se interface{}) {
 	service.lock.Lock()
 	defer service.lock.Unlock()
@@ -27,16 +51,48 @@ func (service *Services) register(name string, se interface{}) {
 	service.services[name] = se
 }
 
-func (service *Services) get(name string) interface{} {
-	if val, ok := service.services[name]; ok {
-		return val
+func (service *Services) add(name string, se interface{}) error {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+
+	if _, ok := service.services[name]; ok {
+		return fmt.Errorf("%w: %s", ErrRegistered, name)
 	}
+	service.services[name] = se
 	return nil
 }
 
-func Root() string {
-	dir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
-	return dir
+func (service *Services) lookup(name string) (interface{}, error) {
+	service.lock.Lock()
+	val, ok := service.services[name]
+	service.lock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotRegistered, name)
+	}
+	if p, ok := val.(*provider); ok {
+		return p.resolve()
+	}
+	return val, nil
+}
+
+func (service *Services) names() []string {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+
+	names := make([]string, 0, len(service.services))
+	for name := range service.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (service *Services) get(name string) interface{} {
+	val, err := service.lookup(name)
+	if err != nil {
+		return nil
+	}
+	return val
 }
 
 func Name() string {
@@ -52,3 +108,66 @@ func Register(name string, service interface{}) interface{} {
 func Get(name string) interface{} {
 	return services.get(name)
 }
+
+// ServiceNames lists the registered services, typed ones by their registry key.
+func ServiceNames() []string {
+	return services.names()
+}
+
+// serviceKey is the registry key of a typed service, e.g. "*github.com/go-redis/redis.Client" or
+// "*github.com/go-redis/redis.Client#cache".
+func serviceKey[T any](name []string) string {
+	key := typeKey(reflect.TypeOf((*T)(nil)).Elem())
+	if len(name) > 0 && name[0] != "" {
+		key += "#" + name[0]
+	}
+	return key
+}
+
+// typeKey names t with the full path of its package, two packages named alike don't collide.
+func typeKey(t reflect.Type) string {
+	switch {
+	case t.Name() != "" && t.PkgPath() != "":
+		return t.PkgPath() + "." + t.Name()
+	case t.Kind() == reflect.Ptr:
+		return "*" + typeKey(t.Elem())
+	case t.Kind() == reflect.Slice:
+		return "[]" + typeKey(t.Elem())
+	case t.Kind() == reflect.Map:
+		return "map[" + typeKey(t.Key()) + "]" + typeKey(t.Elem())
+	}
+	return t.String()
+}
+
+// Provide registers service keyed by its type and an optional name.
+func Provide[T any](service T, name ...string) error {
+	return services.add(serviceKey[T](name), service)
+}
+
+// ProvideFunc registers a factory that is called once, on the first Resolve.
+func ProvideFunc[T any](factory func() (T, error), name ...string) error {
+	return services.add(serviceKey[T](name), &provider{factory: func() (interface{}, error) {
+		return factory()
+	}})
+}
+
+func Resolve[T any](name ...string) (T, error) {
+	var zero T
+	val, err := services.lookup(serviceKey[T](name))
+	if err != nil {
+		return zero, err
+	}
+	se, ok := val.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is a %T", ErrTypeMismatch, serviceKey[T](name), val)
+	}
+	return se, nil
+}
+
+func MustResolve[T any](name ...string) T {
+	se, err := Resolve[T](name...)
+	if err != nil {
+		panic(err)
+	}
+	return se
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"errors"
+	htmltemplate "html/template"
+	"testing"
+	"text/template"
+)
+
+type testService struct {
+	name string
+}
+
+// resetServices empties the service registry once t is done, so the tests can run again.
+func resetServices(t *testing.T) {
+	t.Cleanup(func() {
+		services.lock.Lock()
+		services.services = make(map[string]interface{})
+		services.lock.Unlock()
+	})
+}
+
+func TestProvideResolve(t *testing.T) {
+	resetServices(t)
+	if err := Provide(&testService{name: "default"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Provide(&testService{name: "cache"}, "cache"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Provide(&testService{}); !errors.Is(err, ErrRegistered) {
+		t.Fatalf("expected duplicate error, got %v", err)
+	}
+	if se := MustResolve[*testService](); se.name != "default" {
+		t.Fatalf("unexpected service %q", se.name)
+	}
+	if se := MustResolve[*testService]("cache"); se.name != "cache" {
+		t.Fatalf("unexpected service %q", se.name)
+	}
+	if _, err := Resolve[*testService]("missing"); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("expected not registered error, got %v", err)
+	}
+}
+
+func TestProvideFunc(t *testing.T) {
+	resetServices(t)
+	calls := 0
+	err := ProvideFunc(func() (testService, error) {
+		calls++
+		return testService{name: "lazy"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatal("factory called before resolve")
+	}
+	for i := 0; i < 2; i++ {
+		if se := MustResolve[testService](); se.name != "lazy" {
+			t.Fatalf("unexpected service %q", se.name)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("factory called %d times", calls)
+	}
+}
+
+func TestRegisterGet(t *testing.T) {
+	resetServices(t)
+	Register("legacy", 1)
+	Register("legacy", 2)
+	if Get("legacy") != 2 {
+		t.Fatal("register should overwrite")
+	}
+	if Get("nope") != nil {
+		t.Fatal("expected nil for unknown service")
+	}
+}
+
+func TestServiceKey(t *testing.T) {
+	resetServices(t)
+	// both are template.Template to reflect.Type.String
+	if err := Provide(template.New("text")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Provide(htmltemplate.New("html")); err != nil {
+		t.Fatal(err)
+	}
+	if MustResolve[*template.Template]().Name() != "text" || MustResolve[*htmltemplate.Template]().Name() != "html" {
+		t.Fatal("services of same named types collide")
+	}
+	Register(serviceKey[int]([]string{"port"}), "8080")
+	if _, err := Resolve[int]("port"); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected type mismatch error, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Set at link time, e.g.
+// go build -ldflags "-X github.com/holgerfy/go-pkg/app.version=v1.2.0 -X github.com/holgerfy/go-pkg/app.gitCommit=$(git rev-parse HEAD) -X github.com/holgerfy/go-pkg/app.buildTime=$(date -u +%FT%TZ)"
+var (
+	version   string
+	gitCommit string
+	buildTime string
+)
+
+const (
+	VersionHeader = "x-app-version"
+	CommitHeader  = "x-app-commit"
+)
+
+type Build struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+var build struct {
+	once sync.Once
+	info Build
+}
+
+// BuildInfo returns the -ldflags values, missing ones are taken from the module build info.
+func BuildInfo() Build {
+	build.once.Do(func() {
+		info := Build{
+			Version:   version,
+			GitCommit: gitCommit,
+			BuildTime: buildTime,
+			GoVersion: runtime.Version(),
+		}
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			if info.Version == "" {
+				info.Version = bi.Main.Version
+			}
+			for _, setting := range bi.Settings {
+				switch {
+				case setting.Key == "vcs.revision" && info.GitCommit == "":
+					info.GitCommit = setting.Value
+				case setting.Key == "vcs.time" && info.BuildTime == "":
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+		build.info = info
+	})
+	return build.info
+}
+
+func VersionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(BuildInfo())
+	})
+}
+
+func versionMetadata() metadata.MD {
+	info := BuildInfo()
+	return metadata.Pairs(VersionHeader, info.Version, CommitHeader, info.GitCommit)
+}
+
+// GrpcVersionUnaryServerInterceptor sends the version and commit as response headers.
+func GrpcVersionUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		grpc.SetHeader(ctx, versionMetadata())
+		return handler(ctx, req)
+	}
+}
+
+func GrpcVersionStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ss.SetHeader(versionMetadata())
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// setBuild sets the values -ldflags would and forgets the cached BuildInfo.
+func setBuild(t *testing.T, v, commit string) {
+	prevVersion, prevCommit := version, gitCommit
+	version, gitCommit = v, commit
+	build.once = sync.Once{}
+	t.Cleanup(func() {
+		version, gitCommit = prevVersion, prevCommit
+		build.once = sync.Once{}
+	})
+}
+
+func TestBuildInfoLdflags(t *testing.T) {
+	setBuild(t, "v1.2.0", "0123abc")
+	info := BuildInfo()
+	if info.Version != "v1.2.0" || info.GitCommit != "0123abc" || info.GoVersion != runtime.Version() {
+		t.Fatalf("unexpected build info %+v", info)
+	}
+}
+
+func TestVersionHandler(t *testing.T) {
+	setBuild(t, "v2.0.1", "fedcba9")
+	rec := httptest.NewRecorder()
+	VersionHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("version returned %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var info Build
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "v2.0.1" || info.GitCommit != "fedcba9" {
+		t.Fatalf("unexpected version %+v", info)
+	}
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Command is a subcommand of the binary, e.g. "serve" or "task backfill". Flags declares the
+// command flags, Run gets them parsed together with a context that is cancelled on SIGINT/SIGTERM.
+// A command with Subcommands and no Run only groups them.
+type Command struct {
+	Name        string
+	Usage       string
+	Flags       func(fs *flag.FlagSet)
+	Run         func(ctx context.Context, fs *flag.FlagSet) error
+	Subcommands []*Command
+}
+
+var (
+	ErrNoCommand      = errors.New("no command given")
+	ErrUnknownCommand = errors.New("unknown command")
+
+	commands = &commandSet{commands: make(map[string]*Command)}
+)
+
+type commandSet struct {
+	lock     sync.Mutex
+	commands map[string]*Command
+}
+
+func AddCommand(cmd *Command) error {
+	commands.lock.Lock()
+	defer commands.lock.Unlock()
+
+	if _, ok := commands.commands[cmd.Name]; ok {
+		return fmt.Errorf("command %s already registered", cmd.Name)
+	}
+	commands.commands[cmd.Name] = cmd
+	return nil
+}
+
+// ServeCommand runs the lifecycle until a signal arrives.
+func ServeCommand() *Command {
+	return &Command{
+		Name:  "serve",
+		Usage: "start the components and serve until SIGINT/SIGTERM",
+		Run: func(ctx context.Context, fs *flag.FlagSet) error {
+			<-ctx.Done()
+			return nil
+		},
+	}
+}
+
+// Execute runs the command named by os.Args with the components started around it.
+func Execute(components ...*Component) error {
+	return lifecycle.Execute(os.Args[1:], components...)
+}
+
+func (lc *Lifecycle) Execute(args []string, components ...*Component) error {
+	global, args := globalFlags(args)
+	commands.lock.Lock()
+	cmd, path, rest := findCommand(commands.commands, args)
+	commands.lock.Unlock()
+
+	if cmd == nil {
+		printUsage(os.Stderr)
+		if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			return ErrNoCommand
+		}
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+	}
+	if cmd.Run == nil {
+		printUsage(os.Stderr)
+		return fmt.Errorf("%w: %s needs a subcommand", ErrUnknownCommand, path)
+	}
+
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	// consumed by Root and the config package, declared so that parsing accepts them
+	fs.String("root", "", "application root dir")
+	fs.Var(new(listFlag), "set", "override a config key, e.g. --set db.redis.addr=redis:6379")
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+	if err := fs.Parse(append(global, rest...)); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	lc.Append(components...)
+	if err := lc.Start(ctx); err != nil {
+		return err
+	}
+	runErr := cmd.Run(ctx, fs)
+	if runErr != nil {
+		runErr = fmt.Errorf("%s: %w", path, runErr)
+	}
+	if err := lc.Stop(context.Background()); err != nil {
+		if runErr != nil {
+			return Errors{runErr, err}
+		}
+		return err
+	}
+	return runErr
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(val string) error {
+	*l = append(*l, val)
+	return nil
+}
+
+// globalFlags splits the --root and --set flags given before the command name from the other
+// args, so ./svc --root /srv serve runs serve. They can also follow the command.
+func globalFlags(args []string) ([]string, []string) {
+	var global []string
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		name := strings.TrimLeft(args[0], "-")
+		switch {
+		case name == "root" || name == "set":
+			n := 2
+			if len(args) < n {
+				n = 1
+			}
+			global, args = append(global, args[:n]...), args[n:]
+		case strings.HasPrefix(name, "root=") || strings.HasPrefix(name, "set="):
+			global, args = append(global, args[0]), args[1:]
+		default:
+			return global, args
+		}
+	}
+	return global, args
+}
+
+// findCommand walks the subcommands named by args and returns the deepest match,
+// its full name and the remaining args.
+func findCommand(set map[string]*Command, args []string) (*Command, string, []string) {
+	if len(args) == 0 {
+		return nil, "", nil
+	}
+	cmd, ok := set[args[0]]
+	if !ok {
+		return nil, "", nil
+	}
+	path := []string{cmd.Name}
+	args = args[1:]
+	for len(args) > 0 {
+		var sub *Command
+		for _, c := range cmd.Subcommands {
+			if c.Name == args[0] {
+				sub = c
+				break
+			}
+		}
+		if sub == nil {
+			break
+		}
+		cmd = sub
+		path = append(path, sub.Name)
+		args = args[1:]
+	}
+	return cmd, strings.Join(path, " "), args
+}
+
+func printUsage(w io.Writer) {
+	commands.lock.Lock()
+	defer commands.lock.Unlock()
+
+	names := make([]string, 0, len(commands.commands))
+	for name := range commands.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, name := range names {
+		printCommand(w, commands.commands[name], "")
+	}
+}
+
+func printCommand(w io.Writer, cmd *Command, prefix string) {
+	name := strings.TrimSpace(prefix + " " + cmd.Name)
+	fmt.Fprintf(w, "  %-24s %s\n", name, cmd.Usage)
+	for _, sub := range cmd.Subcommands {
+		printCommand(w, sub, name)
+	}
+}
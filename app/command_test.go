@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	var from string
+	var events []string
+	err := AddCommand(&Command{
+		Name: "task",
+		Subcommands: []*Command{{
+			Name: "backfill",
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&from, "from", "", "start date")
+			},
+			Run: func(ctx context.Context, fs *flag.FlagSet) error {
+				events = append(events, "run "+from)
+				return nil
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		commands.lock.Lock()
+		delete(commands.commands, "task")
+		commands.lock.Unlock()
+	})
+
+	lc := NewLifecycle()
+	if err := lc.Execute([]string{"task", "backfill", "--from=20220101"}, recordComponent("mongo", &events, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(events, []string{"start mongo", "run 20220101", "stop mongo"}) {
+		t.Fatalf("unexpected events %v", events)
+	}
+
+	// the global flags can come before the command
+	events = nil
+	args := []string{"--root", "/srv", "--set=db.redis.addr=redis:6379", "task", "backfill", "--from=20230101"}
+	if err := NewLifecycle().Execute(args); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(events, []string{"run 20230101"}) {
+		t.Fatalf("unexpected events %v", events)
+	}
+
+	if err := NewLifecycle().Execute([]string{"task"}); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("expected unknown command, got %v", err)
+	}
+	if err := NewLifecycle().Execute([]string{"migrate"}); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("expected unknown command, got %v", err)
+	}
+}
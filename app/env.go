@@ -0,0 +1,89 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/holgerfy/go-pkg/funcs"
+)
+
+// Environment is the validated value of RUN_ENV, an empty RUN_ENV means release.
+type Environment string
+
+// Profile holds the defaults an environment implies for the other packages.
+type Profile struct {
+	LogLevel       string // zap level name: debug, info, warn, error
+	LogEncoder     string // console or json
+	StrictTLS      bool   // verify server certificates
+	DebugEndpoints bool   // expose pprof and other debug handlers
+	StackTraces    bool   // attach stack traces to error logs
+}
+
+var (
+	ErrUnknownEnv = errors.New("unknown RUN_ENV")
+
+	env struct {
+		once sync.Once
+		val  Environment
+		err  error
+	}
+	profileLock sync.RWMutex
+	profiles    = map[Environment]Profile{
+		EnvModelRelease: {LogLevel: "info", LogEncoder: "json", StrictTLS: true},
+		EnvModelDebug:   {LogLevel: "debug", LogEncoder: "console", StrictTLS: true, DebugEndpoints: true, StackTraces: true},
+		EnvModelDev:     {LogLevel: "debug", LogEncoder: "console", StrictTLS: true, DebugEndpoints: true, StackTraces: true},
+		EnvModelLocal:   {LogLevel: "debug", LogEncoder: "console", DebugEndpoints: true, StackTraces: true},
+	}
+)
+
+func ParseEnv(s string) (Environment, error) {
+	if s == "" {
+		return EnvModelRelease, nil
+	}
+	e := Environment(s)
+	profileLock.RLock()
+	_, ok := profiles[e]
+	profileLock.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownEnv, s)
+	}
+	return e, nil
+}
+
+func loadEnv() (Environment, error) {
+	env.once.Do(func() {
+		env.val, env.err = ParseEnv(funcs.GetEnv())
+	})
+	return env.val, env.err
+}
+
+// Env returns the environment from RUN_ENV. An unknown value is rejected when the
+// lifecycle starts, until then Env falls back to release.
+func Env() Environment {
+	e, err := loadEnv()
+	if err != nil {
+		return EnvModelRelease
+	}
+	return e
+}
+
+// SetProfile overrides the profile of an environment, or adds a custom environment.
+func SetProfile(e Environment, profile Profile) {
+	profileLock.Lock()
+	defer profileLock.Unlock()
+
+	profiles[e] = profile
+}
+
+func GetProfile(e Environment) Profile {
+	profileLock.RLock()
+	defer profileLock.RUnlock()
+
+	return profiles[e]
+}
+
+// CurrentProfile is the profile of Env().
+func CurrentProfile() Profile {
+	return GetProfile(Env())
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	if e, err := ParseEnv(""); err != nil || e != EnvModelRelease {
+		t.Fatalf("unexpected %q %v", e, err)
+	}
+	if e, err := ParseEnv("local"); err != nil || GetProfile(e).StrictTLS {
+		t.Fatalf("unexpected %q %v", e, err)
+	}
+	if _, err := ParseEnv("prod"); !errors.Is(err, ErrUnknownEnv) {
+		t.Fatalf("expected unknown env, got %v", err)
+	}
+	SetProfile("staging", Profile{LogLevel: "info", StrictTLS: true})
+	if _, err := ParseEnv("staging"); err != nil {
+		t.Fatal(err)
+	}
+}
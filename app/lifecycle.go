@@ -0,0 +1,277 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const DefaultStopTimeout = 10 * time.Second
+
+// Component is a unit managed by a Lifecycle. Start must not block, long-running work
+// belongs in a goroutine that Stop shuts down. DependsOn names the components that
+// have to be started before this one.
+type Component struct {
+	Name        string
+	DependsOn   []string
+	Start       func(ctx context.Context) error
+	Stop        func(ctx context.Context) error
+	StopTimeout time.Duration
+}
+
+type Lifecycle struct {
+	lock       sync.Mutex
+	components []*Component
+	started    []*Component
+	workers    *workerGroup
+}
+
+// Logger is what the app package needs to report lifecycle events, log.Start installs the zap logger.
+type Logger interface {
+	Info(ctx context.Context, args ...interface{})
+	Error(ctx context.Context, args ...interface{})
+}
+
+// Errors collects the failures of several components.
+type Errors []error
+
+var (
+	ErrDependencyCycle   = errors.New("dependency cycle")
+	ErrMissingDependency = errors.New("missing dependency")
+	ErrDuplicateName     = errors.New("duplicate component")
+
+	lifecycle        = &Lifecycle{workers: newWorkerGroup(services)}
+	logger    Logger = stdLogger{}
+	logBuild  sync.Once
+)
+
+type stdLogger struct{}
+
+func (stdLogger) Info(_ context.Context, args ...interface{}) {
+	stdlog.Print(args...)
+}
+
+func (stdLogger) Error(_ context.Context, args ...interface{}) {
+	stdlog.Print(args...)
+}
+
+func SetLogger(l Logger) {
+	logger = l
+}
+
+func (errs Errors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (errs Errors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (errs Errors) As(target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewLifecycle returns a lifecycle whose workers are registered apart from the default one's.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{workers: newWorkerGroup(&Services{services: make(map[string]interface{})})}
+}
+
+func (lc *Lifecycle) Append(components ...*Component) {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+
+	lc.components = append(lc.components, components...)
+}
+
+// Start starts the components in dependency order, then the workers. If a component fails, the ones
+// already started are stopped again.
+func (lc *Lifecycle) Start(ctx context.Context) error {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+
+	if _, err := loadEnv(); err != nil {
+		return err
+	}
+	logBuild.Do(func() {
+		info := BuildInfo()
+		logger.Info(ctx, "starting, env: ", Env(), ", version: ", info.Version, ", commit: ", info.GitCommit,
+			", build time: ", info.BuildTime, ", go: ", info.GoVersion)
+	})
+	components, err := sortComponents(lc.components)
+	if err != nil {
+		return err
+	}
+	for _, c := range components {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", c.Name, err)
+				if stopErr := lc.stop(ctx); stopErr != nil {
+					return Errors{err, stopErr}
+				}
+				return err
+			}
+		}
+		lc.started = append(lc.started, c)
+		logger.Info(ctx, "component started: ", c.Name)
+	}
+	lc.workers.start()
+	return nil
+}
+
+// Stop stops the workers, then the started components in reverse order, each one bounded by its StopTimeout.
+func (lc *Lifecycle) Stop(ctx context.Context) error {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+
+	return lc.stop(ctx)
+}
+
+func (lc *Lifecycle) stop(ctx context.Context) error {
+	var errs Errors
+	workerCtx, cancel := context.WithTimeout(ctx, DefaultStopTimeout)
+	if err := lc.workers.stop(workerCtx); err != nil {
+		errs = append(errs, err)
+		logger.Error(ctx, err)
+	}
+	cancel()
+	for i := len(lc.started) - 1; i >= 0; i-- {
+		c := lc.started[i]
+		if c.Stop == nil {
+			continue
+		}
+		timeout := c.StopTimeout
+		if timeout <= 0 {
+			timeout = DefaultStopTimeout
+		}
+		stopCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := stopComponent(stopCtx, c); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.Name, err))
+			logger.Error(ctx, "failed to stop component ", c.Name, ", err: ", err)
+		} else {
+			logger.Info(ctx, "component stopped: ", c.Name)
+		}
+		cancel()
+	}
+	lc.started = nil
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// sortComponents orders the components so that each one comes after its dependencies,
+// otherwise keeping the order they were appended in.
+func sortComponents(components []*Component) ([]*Component, error) {
+	byName := make(map[string]*Component, len(components))
+	for _, c := range components {
+		if _, ok := byName[c.Name]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateName, c.Name)
+		}
+		byName[c.Name] = c
+	}
+	for _, c := range components {
+		for _, dep := range c.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("%w: %s depends on %s, which is not registered", ErrMissingDependency, c.Name, dep)
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(components))
+	sorted := make([]*Component, 0, len(components))
+	var path []string
+	var visit func(c *Component) error
+	visit = func(c *Component) error {
+		switch state[c.Name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, name := range path {
+				if name == c.Name {
+					path = append(path[i:], c.Name)
+					break
+				}
+			}
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(path, " -> "))
+		}
+		state[c.Name] = visiting
+		path = append(path, c.Name)
+		for _, dep := range c.DependsOn {
+			if err := visit(byName[dep]); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[c.Name] = visited
+		sorted = append(sorted, c)
+		return nil
+	}
+	for _, c := range components {
+		if err := visit(c); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// stopComponent gives up waiting once ctx expires, even if Stop ignores the context.
+func stopComponent(ctx context.Context, c *Component) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Stop(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run starts the components, blocks until ctx is done or SIGINT/SIGTERM arrives, then stops them.
+func (lc *Lifecycle) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := lc.Start(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	logger.Info(context.Background(), "shutting down")
+	return lc.Stop(context.Background())
+}
+
+// Run registers the components on the default lifecycle and runs it.
+func Run(components ...*Component) error {
+	lifecycle.Append(components...)
+	return lifecycle.Run(context.Background())
+}
+
+func Default() *Lifecycle {
+	return lifecycle
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func recordComponent(name string, events *[]string, stopErr error) *Component {
+	return &Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			*events = append(*events, "start "+name)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			*events = append(*events, "stop "+name)
+			return stopErr
+		},
+	}
+}
+
+func TestLifecycleOrder(t *testing.T) {
+	var events []string
+	lc := NewLifecycle()
+	lc.Append(recordComponent("log", &events, nil), recordComponent("config", &events, nil), recordComponent("mongo", &events, errors.New("boom")))
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := lc.Stop(context.Background())
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected one stop error, got %v", err)
+	}
+	expected := []string{"start log", "start config", "start mongo", "stop mongo", "stop config", "stop log"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("unexpected events %v", events)
+	}
+}
+
+func TestLifecycleStartFailure(t *testing.T) {
+	var events []string
+	lc := NewLifecycle()
+	lc.Append(recordComponent("log", &events, nil), &Component{
+		Name: "redis",
+		Start: func(ctx context.Context) error {
+			return errors.New("refused")
+		},
+	})
+	if err := lc.Start(context.Background()); err == nil {
+		t.Fatal("expected start error")
+	}
+	if !reflect.DeepEqual(events, []string{"start log", "stop log"}) {
+		t.Fatalf("unexpected events %v", events)
+	}
+}
+
+func TestLifecycleStopTimeout(t *testing.T) {
+	lc := NewLifecycle()
+	lc.Append(&Component{
+		Name:        "slow",
+		StopTimeout: 10 * time.Millisecond,
+		Stop: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := lc.Stop(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestLifecycleDependencyOrder(t *testing.T) {
+	var events []string
+	mongo := recordComponent("mongo", &events, nil)
+	mongo.DependsOn = []string{"config", "log"}
+	config := recordComponent("config", &events, nil)
+	config.DependsOn = []string{"log"}
+	lc := NewLifecycle()
+	lc.Append(mongo, config, recordComponent("log", &events, nil))
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(events, []string{"start log", "start config", "start mongo"}) {
+		t.Fatalf("unexpected events %v", events)
+	}
+}
+
+func TestLifecycleDependencyErrors(t *testing.T) {
+	a := &Component{Name: "a", DependsOn: []string{"b"}}
+	b := &Component{Name: "b", DependsOn: []string{"a"}}
+	lc := NewLifecycle()
+	lc.Append(a, b)
+	err := lc.Start(context.Background())
+	if !errors.Is(err, ErrDependencyCycle) || err.Error() != "dependency cycle: a -> b -> a" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	lc = NewLifecycle()
+	lc.Append(&Component{Name: "mongo", DependsOn: []string{"config"}})
+	if err := lc.Start(context.Background()); !errors.Is(err, ErrMissingDependency) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
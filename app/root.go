@@ -0,0 +1,95 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const RootEnv = "APP_ROOT"
+
+// RootMarkers are glob patterns identifying the application root when walking up from the working
+// directory: an .approot file or a config dir holding config files. A bare config dir could be a Go package.
+var RootMarkers = []string{".approot", "config/*.toml", "config/*.yaml", "config/*.yml", "config/*.json", "config/*.env"}
+
+var root struct {
+	lock     sync.Mutex
+	explicit string
+	dir      string
+}
+
+// SetRoot sets the application root explicitly, it takes precedence over everything else.
+func SetRoot(dir string) {
+	root.lock.Lock()
+	defer root.lock.Unlock()
+
+	root.explicit = dir
+	root.dir = ""
+}
+
+// Root resolves the application root from, in order: SetRoot, the APP_ROOT env, the --root flag,
+// the closest parent of the working directory holding one of RootMarkers, and the binary dir.
+// The result is resolved once and cached.
+func Root() string {
+	root.lock.Lock()
+	defer root.lock.Unlock()
+
+	if root.dir == "" {
+		root.dir = resolveRoot()
+	}
+	return root.dir
+}
+
+func resolveRoot() string {
+	for _, dir := range []string{root.explicit, os.Getenv(RootEnv), rootFlag(os.Args[1:])} {
+		if dir != "" {
+			if abs, err := filepath.Abs(dir); err == nil {
+				return abs
+			}
+			return dir
+		}
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if dir := findMarker(wd); dir != "" {
+			return dir
+		}
+	}
+	dir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
+	return dir
+}
+
+// rootFlag looks for --root without touching flag.CommandLine, so commands can define their own flags.
+func rootFlag(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == "root" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(name, "root=") {
+			return strings.TrimPrefix(name, "root=")
+		}
+	}
+	return ""
+}
+
+func findMarker(dir string) string {
+	for {
+		for _, marker := range RootMarkers {
+			if matches, _ := filepath.Glob(filepath.Join(dir, marker)); len(matches) > 0 {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
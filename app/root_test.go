@@ -0,0 +1,47 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRootFlag(t *testing.T) {
+	cases := map[string][]string{
+		"/srv/a": {"serve", "--root", "/srv/a"},
+		"/srv/b": {"-root=/srv/b", "serve"},
+		"":       {"serve", "--", "--root=/srv/c"},
+	}
+	for expected, args := range cases {
+		if dir := rootFlag(args); dir != expected {
+			t.Fatalf("rootFlag(%v) = %q, expected %q", args, dir, expected)
+		}
+	}
+}
+
+func TestFindMarker(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "database", "mongo")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// a config package is not a marker, a config dir with config files is
+	if err := os.MkdirAll(filepath.Join(dir, "database", "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "database", "config", "config.go"), []byte("package config\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if found := findMarker(nested); found != "" {
+		t.Fatalf("found %q without config files", found)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config", "db.toml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if found := findMarker(nested); found != dir {
+		t.Fatalf("found %q, expected %q", found, dir)
+	}
+}
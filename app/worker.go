@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/holgerfy/go-pkg/funcs"
+)
+
+type WorkerState string
+
+const (
+	WorkerPending    WorkerState = "pending"
+	WorkerRunning    WorkerState = "running"
+	WorkerRestarting WorkerState = "restarting"
+	WorkerFailed     WorkerState = "failed"
+	WorkerStopped    WorkerState = "stopped"
+)
+
+// WorkerOptions controls restarts, zero values take the defaults below.
+type WorkerOptions struct {
+	MinBackoff    time.Duration // first restart delay, doubled on every restart until a run outlasts it, default 1s
+	MaxBackoff    time.Duration // default 1m
+	MaxRestarts   int           // restarts allowed within RestartWindow before the worker is failed, default 10
+	RestartWindow time.Duration // default 1m
+}
+
+// Worker is a supervised goroutine, registered under its name in the registry of its lifecycle,
+// the service registry for the default one.
+type Worker struct {
+	Name     string
+	opts     WorkerOptions
+	fn       func(ctx context.Context) error
+	lock     sync.Mutex
+	state    WorkerState
+	restarts int
+	err      error
+}
+
+// workerGroup holds the workers of a lifecycle. They are queued until the lifecycle has started,
+// and launched again with a fresh context when it starts after a stop.
+type workerGroup struct {
+	registry *Services
+	lock     sync.Mutex
+	running  bool
+	workers  []*Worker
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func newWorkerGroup(registry *Services) *workerGroup {
+	return &workerGroup{registry: registry}
+}
+
+// add launches w if the group is running, otherwise it waits for start.
+func (g *workerGroup) add(w *Worker) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.workers = append(g.workers, w)
+	if g.running {
+		g.launch(w)
+	}
+}
+
+// start launches the workers under a new context.
+func (g *workerGroup) start() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.running {
+		return
+	}
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	g.running = true
+	for _, w := range g.workers {
+		g.launch(w)
+	}
+}
+
+func (g *workerGroup) launch(w *Worker) {
+	w.setState(WorkerRunning, nil)
+	g.wg.Add(1)
+	go func(ctx context.Context) {
+		defer g.wg.Done()
+		w.supervise(ctx)
+	}(g.ctx)
+}
+
+// stop cancels the workers and waits for them to return.
+func (g *workerGroup) stop(ctx context.Context) error {
+	g.lock.Lock()
+	if g.running {
+		g.cancel()
+		g.running = false
+	}
+	g.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stop workers: %w", ctx.Err())
+	}
+}
+
+// Go runs fn on the default lifecycle, see Lifecycle.Go.
+func Go(name string, fn func(ctx context.Context) error, opts ...WorkerOptions) (*Worker, error) {
+	return lifecycle.Go(name, fn, opts...)
+}
+
+// Go runs fn in a supervised goroutine. A panic or an error restarts it with exponential
+// backoff, returning nil stops it until the lifecycle starts again. Workers added before
+// Start has finished are pending until then. The workers are stopped before the components
+// when the lifecycle shuts down.
+func (lc *Lifecycle) Go(name string, fn func(ctx context.Context) error, opts ...WorkerOptions) (*Worker, error) {
+	w := &Worker{Name: name, fn: fn, state: WorkerPending}
+	if len(opts) > 0 {
+		w.opts = opts[0]
+	}
+	if w.opts.MinBackoff <= 0 {
+		w.opts.MinBackoff = time.Second
+	}
+	if w.opts.MaxBackoff <= 0 {
+		w.opts.MaxBackoff = time.Minute
+	}
+	if w.opts.MaxRestarts <= 0 {
+		w.opts.MaxRestarts = 10
+	}
+	if w.opts.RestartWindow <= 0 {
+		w.opts.RestartWindow = time.Minute
+	}
+	if err := lc.workers.registry.add(serviceKey[*Worker]([]string{name}), w); err != nil {
+		return nil, err
+	}
+	lc.workers.add(w)
+	return w, nil
+}
+
+// Worker returns the worker of lc named name.
+func (lc *Lifecycle) Worker(name string) (*Worker, error) {
+	val, err := lc.workers.registry.lookup(serviceKey[*Worker]([]string{name}))
+	if err != nil {
+		return nil, err
+	}
+	w, ok := val.(*Worker)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is a %T", ErrTypeMismatch, name, val)
+	}
+	return w, nil
+}
+
+func (w *Worker) supervise(ctx context.Context) {
+	backoff := w.opts.MinBackoff
+	var restarts []time.Time
+	for {
+		started := time.Now()
+		err := w.run(ctx)
+		if ctx.Err() != nil || err == nil {
+			w.setState(WorkerStopped, err)
+			return
+		}
+		logger.Error(ctx, "worker ", w.Name, " failed, err: ", err)
+		// a run that outlasted the backoff was healthy, the failure starts a new series
+		if time.Since(started) > backoff {
+			backoff = w.opts.MinBackoff
+		}
+
+		now := time.Now()
+		for len(restarts) > 0 && now.Sub(restarts[0]) > w.opts.RestartWindow {
+			restarts = restarts[1:]
+		}
+		if len(restarts) >= w.opts.MaxRestarts {
+			w.setState(WorkerFailed, err)
+			logger.Error(ctx, "worker ", w.Name, " restarted ", len(restarts), " times in ", w.opts.RestartWindow, ", giving up")
+			return
+		}
+		restarts = append(restarts, now)
+		w.setState(WorkerRestarting, err)
+
+		select {
+		case <-ctx.Done():
+			w.setState(WorkerStopped, err)
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > w.opts.MaxBackoff {
+			backoff = w.opts.MaxBackoff
+		}
+		w.lock.Lock()
+		w.restarts++
+		w.state = WorkerRunning
+		w.lock.Unlock()
+	}
+}
+
+func (w *Worker) run(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			trace := funcs.PanicTrace(r)
+			logger.Error(ctx, "worker ", w.Name, " panic: ", trace)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return w.fn(ctx)
+}
+
+func (w *Worker) setState(state WorkerState, err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.state = state
+	w.err = err
+}
+
+func (w *Worker) State() WorkerState {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.state
+}
+
+func (w *Worker) Restarts() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.restarts
+}
+
+// Err is the last error the worker returned.
+func (w *Worker) Err() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.err
+}
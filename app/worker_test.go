@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitState(t *testing.T, w *Worker, state WorkerState) {
+	deadline := time.Now().Add(time.Second)
+	for w.State() != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("worker %s is %s, expected %s", w.Name, w.State(), state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWorkerRestart(t *testing.T) {
+	lc := NewLifecycle()
+	var runs int32
+	w, err := lc.Go("panicky", func(ctx context.Context) error {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("boom")
+		}
+		<-ctx.Done()
+		return nil
+	}, WorkerOptions{MinBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := lc.Worker("panicky"); err != nil || got != w {
+		t.Fatalf("worker is not registered: %v", err)
+	}
+	// lifecycles register their workers apart
+	if _, err := NewLifecycle().Go("panicky", func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lc.Go("panicky", func(ctx context.Context) error { return nil }); !errors.Is(err, ErrRegistered) {
+		t.Fatalf("expected duplicate error, got %v", err)
+	}
+	for atomic.LoadInt32(&runs) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	waitState(t, w, WorkerRunning)
+	if w.Restarts() != 1 {
+		t.Fatalf("expected one restart, got %d", w.Restarts())
+	}
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, w, WorkerStopped)
+}
+
+func TestWorkerRestartLimit(t *testing.T) {
+	lc := NewLifecycle()
+	w, err := lc.Go("failing", func(ctx context.Context) error {
+		return errors.New("unavailable")
+	}, WorkerOptions{MinBackoff: time.Millisecond, MaxRestarts: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, w, WorkerFailed)
+	if w.Restarts() != 3 || w.Err() == nil {
+		t.Fatalf("unexpected restarts %d, err %v", w.Restarts(), w.Err())
+	}
+}
+
+func TestWorkerStartsWithLifecycle(t *testing.T) {
+	lc := NewLifecycle()
+	var fail int32 = 1
+	lc.Append(&Component{Name: "flaky", Start: func(ctx context.Context) error {
+		if atomic.LoadInt32(&fail) == 1 {
+			return errors.New("unavailable")
+		}
+		return nil
+	}})
+	var runs int32
+	run := func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-ctx.Done()
+		return nil
+	}
+	pending, err := lc.Go("pending", run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lc.Start(context.Background()); err == nil {
+		t.Fatal("expected the start to fail")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if pending.State() != WorkerPending || atomic.LoadInt32(&runs) != 0 {
+		t.Fatalf("worker ran before the lifecycle started, state %s", pending.State())
+	}
+
+	// the stop after the failed start doesn't prevent the next one
+	atomic.StoreInt32(&fail, 0)
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, pending, WorkerRunning)
+	late, err := lc.Go("late", run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, late, WorkerRunning)
+	for atomic.LoadInt32(&runs) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, pending, WorkerStopped)
+	waitState(t, late, WorkerStopped)
+}
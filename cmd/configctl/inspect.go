@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/holgerfy/go-pkg/app"
+	"github.com/holgerfy/go-pkg/config"
+	// register their Bind targets for check
+	_ "github.com/holgerfy/go-pkg/admin"
+	_ "github.com/holgerfy/go-pkg/database/mongo"
+	_ "github.com/holgerfy/go-pkg/flags"
+	_ "github.com/holgerfy/go-pkg/ratelimit"
+	_ "github.com/holgerfy/go-pkg/redis"
+)
+
+// load reads the config under the app root like the services do, --set flags included.
+func load() (*config.Config, error) {
+	conf, err := config.Load(config.Options{})
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range conf.Warnings() {
+		fmt.Fprintln(os.Stderr, "warning:", warning)
+	}
+	return conf, nil
+}
+
+func dumpCommand() *app.Command {
+	return &app.Command{
+		Name:  "dump",
+		Usage: "print the effective config with where each value was set, secrets redacted",
+		Run: func(ctx context.Context, fs *flag.FlagSet) error {
+			conf, err := load()
+			if err != nil {
+				return err
+			}
+			printEntries(conf.Entries())
+			return nil
+		},
+	}
+}
+
+func getCommand() *app.Command {
+	return &app.Command{
+		Name:  "get",
+		Usage: "print the value at a path like db.redis.addr, or every value under a table, secrets redacted",
+		Run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() != 1 {
+				return errors.New("usage: get <path>")
+			}
+			conf, err := load()
+			if err != nil {
+				return err
+			}
+			path := strings.ToLower(strings.NewReplacer("[", ".", "]", "").Replace(fs.Arg(0)))
+			var entries []config.Entry
+			for _, entry := range conf.Entries() {
+				entryPath := strings.ToLower(entry.Path)
+				if entryPath == path || strings.HasPrefix(entryPath, path+".") || strings.HasPrefix(path, entryPath+".") {
+					entries = append(entries, entry)
+				}
+			}
+			switch {
+			case len(entries) == 0:
+				return fmt.Errorf("%s is not set", fs.Arg(0))
+			case len(entries) == 1 && strings.ToLower(entries[0].Path) == path:
+				fmt.Println(format(entries[0].Value))
+			default:
+				printEntries(entries)
+			}
+			return nil
+		},
+	}
+}
+
+func checkCommand() *app.Command {
+	return &app.Command{
+		Name:  "check",
+		Usage: "bind every registered config target and report the invalid ones",
+		Run: func(ctx context.Context, fs *flag.FlagSet) error {
+			conf, err := load()
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for _, res := range conf.Check() {
+				switch {
+				case res.Err == nil:
+					fmt.Println("ok     ", res.Path)
+				case errors.Is(res.Err, config.ErrNodeNotExists):
+					fmt.Println("not set", res.Path)
+				default:
+					failed++
+					fmt.Println("invalid", res.Path+":", conf.RedactString(res.Err.Error()))
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d invalid config targets", failed)
+			}
+			return nil
+		},
+	}
+}
+
+func printEntries(entries []config.Entry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s = %s\t# %s\n", entry.Path, format(entry.Value), entry.Origin)
+	}
+	w.Flush()
+}
+
+func format(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return strconv.Quote(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+	return fmt.Sprint(val)
+}
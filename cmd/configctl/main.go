@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/holgerfy/go-pkg/app"
+	"github.com/holgerfy/go-pkg/config"
+)
+
+func main() {
+	app.AddCommand(encryptCommand())
+	app.AddCommand(keygenCommand())
+	app.AddCommand(dumpCommand())
+	app.AddCommand(getCommand())
+	app.AddCommand(checkCommand())
+	if err := app.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// keyFile is the --keyfile flag, APP_CONFIG_KEYFILE or config.key under the app root.
+func keyFile(file string) string {
+	if file != "" {
+		return file
+	}
+	if file = os.Getenv(config.KeyFileEnv); file != "" {
+		return file
+	}
+	return app.Root() + "/config.key"
+}
+
+func encryptCommand() *app.Command {
+	var file string
+	return &app.Command{
+		Name:  "encrypt",
+		Usage: "encrypt a value, given as argument or on stdin, into an enc:v1: config value",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&file, "keyfile", "", "key file, defaults to $"+config.KeyFileEnv+" or config.key under the app root")
+		},
+		Run: func(ctx context.Context, fs *flag.FlagSet) error {
+			key, err := config.ReadKeyFile(keyFile(file))
+			if err != nil {
+				return err
+			}
+			value := fs.Arg(0)
+			if fs.NArg() == 0 {
+				scanner := bufio.NewScanner(os.Stdin)
+				if !scanner.Scan() {
+					return errors.New("no value given")
+				}
+				value = strings.TrimRight(scanner.Text(), "\r\n")
+			}
+			enc, err := config.Encrypt(key, value)
+			if err != nil {
+				return err
+			}
+			fmt.Println(enc)
+			return nil
+		},
+	}
+}
+
+func keygenCommand() *app.Command {
+	var file string
+	return &app.Command{
+		Name:  "keygen",
+		Usage: "write a new random key file",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&file, "keyfile", "", "key file, defaults to $"+config.KeyFileEnv+" or config.key under the app root")
+		},
+		Run: func(ctx context.Context, fs *flag.FlagSet) error {
+			key, err := config.GenerateKey()
+			if err != nil {
+				return err
+			}
+			path := keyFile(file)
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := fmt.Fprintln(f, key); err != nil {
+				return err
+			}
+			fmt.Println("key written to", path)
+			return nil
+		},
+	}
+}
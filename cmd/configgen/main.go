@@ -0,0 +1,313 @@
+// Command configgen generates Go structs with toml tags from a config dir, and functions that Bind them:
+//
+//	//go:generate go run github.com/holgerfy/go-pkg/cmd/configgen -dir ../config -out config_gen.go
+//
+// Node db gets the type DB and BindDB, its table db.redis the type DBRedis and BindDBRedis. Keys
+// whose names end up the same, like db.mongo.options and db.mongo_options, are reported as errors.
+// Regenerating after a key is removed from the files removes its field, so the code using it no
+// longer compiles instead of reading a zero value. Keys set to a non-zero value are required, so a
+// file losing one fails Bind instead of binding a zero value.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/holgerfy/go-pkg/config"
+)
+
+// initialisms are written in upper case in Go names, e.g. url becomes URL.
+var initialisms = map[string]bool{
+	"api": true, "db": true, "dns": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "ssl": true, "tcp": true, "tls": true, "ttl": true, "udp": true,
+	"uri": true, "url": true, "uuid": true,
+}
+
+type field struct {
+	name     string
+	key      string
+	typ      string
+	path     string
+	origin   string
+	required bool
+}
+
+type structType struct {
+	name   string
+	path   string
+	fields []field
+}
+
+type generator struct {
+	conf    *config.Config
+	types   []*structType
+	imports map[string]bool
+	err     error
+}
+
+func main() {
+	var (
+		dir   = flag.String("dir", "config", "config dir, comma separated for several")
+		env   = flag.String("env", "release", "environment whose overlays are merged")
+		pkg   = flag.String("pkg", os.Getenv("GOPACKAGE"), "package of the generated file, default $GOPACKAGE")
+		out   = flag.String("out", "config_gen.go", "generated file")
+		nodes = flag.String("nodes", "", "comma separated nodes to generate, default all")
+	)
+	flag.Parse()
+	if *pkg == "" {
+		*pkg = "config"
+	}
+	src, err := generate(strings.Split(*dir, ","), *env, *pkg, *nodes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "configgen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "configgen:", err)
+		os.Exit(1)
+	}
+}
+
+func generate(dirs []string, env, pkg, only string) ([]byte, error) {
+	conf, err := config.Load(config.Options{Paths: dirs, Env: env, Environ: []string{}, Args: []string{}, KeepSecrets: true})
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{conf: conf, imports: make(map[string]bool)}
+	var binds []*structType
+	for _, node := range conf.Nodes() {
+		if only != "" && !contains(strings.Split(only, ","), node) {
+			continue
+		}
+		t := g.lookup(g.structOf(goName(node), node, conf.GetStringMap(node)))
+		binds = append(binds, t)
+		for _, f := range t.fields {
+			if t := g.lookup(f.typ); t != nil {
+				binds = append(binds, t)
+			}
+		}
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by configgen from %s; DO NOT EDIT.\n\npackage %s\n\nimport (\n", strings.Join(dirs, ", "), pkg)
+	if g.imports["time"] {
+		buf.WriteString("\"time\"\n\n")
+	}
+	buf.WriteString("\"github.com/holgerfy/go-pkg/config\"\n)\n")
+	for _, t := range g.types {
+		if strings.Contains(t.path, ".") {
+			fmt.Fprintf(&buf, "\n// %s is %s.\ntype %s struct {\n", t.name, t.path, t.name)
+		} else {
+			fmt.Fprintf(&buf, "\n// %s is the %s node.\ntype %s struct {\n", t.name, t.path, t.name)
+		}
+		for _, f := range t.fields {
+			if f.origin != "" {
+				fmt.Fprintf(&buf, "// %s is %s, set in %s.\n", f.name, f.path, f.origin)
+			} else {
+				fmt.Fprintf(&buf, "// %s is %s.\n", f.name, f.path)
+			}
+			if f.required {
+				fmt.Fprintf(&buf, "%s %s `toml:%q validate:\"required\"`\n", f.name, f.typ, f.key)
+			} else {
+				fmt.Fprintf(&buf, "%s %s `toml:%q`\n", f.name, f.typ, f.key)
+			}
+		}
+		buf.WriteString("}\n")
+	}
+	for _, t := range binds {
+		node, key, _ := strings.Cut(t.path, ".")
+		fmt.Fprintf(&buf, "\n// Bind%s binds %s of conf, use config.GetInstance() for the loaded one.\n", t.name, t.path)
+		fmt.Fprintf(&buf, "func Bind%s(conf *config.Config) (%s, error) {\nvar res %s\nerr := conf.Bind(%q, %q, &res)\nreturn res, err\n}\n", t.name, t.name, t.name, node, key)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// structOf adds the struct of table, named name, and returns its name.
+func (g *generator) structOf(name, path string, table map[string]interface{}) string {
+	if prev := g.lookup(name); prev != nil {
+		g.fail(fmt.Errorf("%s and %s both generate the type %s, rename one of them", prev.path, path, name))
+		return name
+	}
+	t := &structType{name: name, path: path}
+	g.types = append(g.types, t)
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fieldPath := path + "." + key
+		f := field{name: goName(key), key: key, path: fieldPath}
+		for _, prev := range t.fields {
+			if prev.name == f.name {
+				g.fail(fmt.Errorf("%s and %s both generate the field %s.%s, rename one of them", prev.path, fieldPath, name, f.name))
+			}
+		}
+		f.typ = g.typeOf(name+f.name, fieldPath, table[key])
+		if _, ok := table[key].(map[string]interface{}); !ok {
+			f.origin = g.origin(fieldPath)
+			f.required = nonZero(table[key])
+		}
+		t.fields = append(t.fields, f)
+	}
+	return name
+}
+
+// fail keeps the first error, generate returns it once the types are built.
+func (g *generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+func (g *generator) typeOf(name, path string, val interface{}) string {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return g.structOf(name, path, v)
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return g.typeOf(name, path, items)
+	case []interface{}:
+		if len(v) == 0 {
+			return "[]interface{}"
+		}
+		if table, ok := mergeTables(v); ok {
+			return "[]" + g.structOf(name+"Item", path+"[]", table)
+		}
+		typ := g.typeOf(name, path, v[0])
+		for _, item := range v[1:] {
+			if g.typeOf(name, path, item) != typ {
+				return "[]interface{}"
+			}
+		}
+		return "[]" + typ
+	case string:
+		if _, err := time.ParseDuration(v); err == nil && strings.TrimLeft(v, "+-0123456789.") != "" {
+			g.imports["time"] = true
+			return "time.Duration"
+		}
+		return "string"
+	case int64:
+		return "int"
+	case float64:
+		return "float64"
+	case bool:
+		return "bool"
+	case time.Time:
+		g.imports["time"] = true
+		return "time.Time"
+	}
+	return "interface{}"
+}
+
+// nonZero tells if val binds to a value the required rule accepts.
+func nonZero(val interface{}) bool {
+	if val == nil {
+		return false
+	}
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Slice {
+		return rv.Len() > 0
+	}
+	return !rv.IsZero()
+}
+
+// mergeTables merges the keys of an array of tables, false if an item isn't a table.
+func mergeTables(items []interface{}) (map[string]interface{}, bool) {
+	res := make(map[string]interface{})
+	for _, item := range items {
+		table, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		for key, val := range table {
+			if _, ok := res[key]; !ok {
+				res[key] = val
+			}
+		}
+	}
+	return res, true
+}
+
+func (g *generator) lookup(name string) *structType {
+	for _, t := range g.types {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// origin is the file that set path, relative to the working dir.
+func (g *generator) origin(path string) string {
+	origin := g.conf.Origin(path)
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(origin) {
+		if rel, err := filepath.Rel(wd, origin); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(origin)
+}
+
+// goName turns keys like max_pool_size, replicaSet or user-service into exported names.
+func goName(key string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	for i, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && len(word) > 0 && !unicode.IsUpper(word[len(word)-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	var res strings.Builder
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			res.WriteString(strings.ToUpper(w))
+			continue
+		}
+		runes := []rune(strings.ToLower(w))
+		runes[0] = unicode.ToUpper(runes[0])
+		res.WriteString(string(runes))
+	}
+	name := res.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoName(t *testing.T) {
+	for key, want := range map[string]string{
+		"max_pool_size": "MaxPoolSize",
+		"replicaSet":    "ReplicaSet",
+		"url":           "URL",
+		"is_ssl":        "IsSSL",
+		"user-service":  "UserService",
+		"2fa":           "X2fa",
+	} {
+		if got := goName(key); got != want {
+			t.Errorf("goName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"db.toml":       "[mongo]\nurl = \"mongodb://localhost\"\nmax_pool_size = 100\ntimeout = \"5s\"\npassword = \"${env:MONGO_PASSWORD}\"\n",
+		"db/redis.toml": "addr = \"127.0.0.1:6379\"\nhosts = [\"a\", \"b\"]\ntls = false\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := generate([]string{dir}, "release", "conf", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package conf",
+		"type DBMongo struct",
+		"MaxPoolSize int `toml:\"max_pool_size\" validate:\"required\"`",
+		"TLS bool `toml:\"tls\"`\n",
+		"Timeout time.Duration `toml:\"timeout\" validate:\"required\"`",
+		"Hosts []string `toml:\"hosts\" validate:\"required\"`",
+		"func BindDBRedis(conf *config.Config) (DBRedis, error)",
+		"conf.Bind(\"db\", \"redis\", &res)",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateCollision(t *testing.T) {
+	dir := t.TempDir()
+	content := "[mongo.options]\npool = 1\n\n[mongo_options]\npool = 2\n"
+	if err := os.WriteFile(filepath.Join(dir, "db.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := generate([]string{dir}, "release", "conf", "")
+	if err == nil || !strings.Contains(err.Error(), "DBMongoOptions") {
+		t.Fatalf("expected a collision error, got %v", err)
+	}
+}
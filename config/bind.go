@@ -0,0 +1,299 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Struct tags understood by Bind:
+//
+//	PoolSize int           `toml:"pool_size" default:"10" validate:"min=1,max=100"`
+//	Addr     string        `toml:"addr" validate:"required"`
+//	Encoder  string        `toml:"encoder" default:"json" validate:"oneof=json console"`
+//	Timeout  time.Duration `toml:"timeout" default:"5s"`
+//
+// default applies when the key is missing. required fails on a zero value, min and max compare
+// numbers and durations by value, strings, slices and maps by length. Durations are read from
+// strings like "5s", integers are nanoseconds.
+
+var ErrInvalid = errors.New("invalid config")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+type FieldError struct {
+	Path string
+	Msg  string
+}
+
+// BindError lists every invalid field of a Bind call.
+type BindError struct {
+	Fields []FieldError
+}
+
+func (e *BindError) add(path, msg string) {
+	e.Fields = append(e.Fields, FieldError{Path: path, Msg: msg})
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Path+": "+f.Msg)
+	}
+	return ErrInvalid.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *BindError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// fieldName is the key of a struct field, the toml tag or the field name.
+func fieldName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("toml"), ",")[0]
+	if name == "" {
+		name = f.Name
+	}
+	return name
+}
+
+// lookup finds key the way the decoder does, exactly or else case insensitively.
+func lookup(m map[string]interface{}, key string) (interface{}, bool) {
+	if val, ok := m[key]; ok {
+		return val, true
+	}
+	for k, val := range m {
+		if strings.EqualFold(k, key) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// prepare returns a copy of val with the defaults of t filled in and durations converted.
+func prepare(val interface{}, t reflect.Type, path string, errs *BindError) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		if s, ok := val.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				errs.add(path, fmt.Sprintf("invalid duration %q", s))
+				return nil
+			}
+			return int64(d)
+		}
+		return val
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := val.(time.Time); ok {
+			return val
+		}
+		m, ok := val.(map[string]interface{})
+		if !ok && val != nil {
+			return val
+		}
+		res := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			res[k] = v
+		}
+		prepareStruct(res, t, path, errs)
+		return res
+	case reflect.Slice, reflect.Array:
+		switch items := val.(type) {
+		case []interface{}:
+			res := make([]interface{}, len(items))
+			for i, item := range items {
+				res[i] = prepare(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+			return res
+		case []map[string]interface{}:
+			res := make([]interface{}, len(items))
+			for i, item := range items {
+				res[i] = prepare(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+			return res
+		}
+	case reflect.Map:
+		if m, ok := val.(map[string]interface{}); ok {
+			res := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				res[k] = prepare(v, t.Elem(), path+"."+k, errs)
+			}
+			return res
+		}
+	}
+	return val
+}
+
+func prepareStruct(m map[string]interface{}, t reflect.Type, path string, errs *BindError) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if f.Anonymous && f.Tag.Get("toml") == "" && f.Type.Kind() == reflect.Struct {
+			prepareStruct(m, f.Type, path, errs)
+			continue
+		}
+		name := fieldName(f)
+		if name == "-" {
+			continue
+		}
+		fieldPath := path + "." + name
+		val, ok := lookup(m, name)
+		if !ok {
+			def, hasDefault := f.Tag.Lookup("default")
+			if !hasDefault {
+				continue
+			}
+			defVal, err := parseDefault(f.Type, def)
+			if err != nil {
+				errs.add(fieldPath, fmt.Sprintf("invalid default %q: %v", def, err))
+				continue
+			}
+			val = defVal
+		}
+		m[name] = prepare(val, f.Type, fieldPath, errs)
+	}
+}
+
+func parseDefault(t reflect.Type, s string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return s, nil // converted by prepare
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(s, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(s, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, 64)
+	case reflect.Slice:
+		var res []interface{}
+		for _, item := range strings.Split(s, ",") {
+			v, err := parseDefault(t.Elem(), strings.TrimSpace(item))
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, v)
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// check validates the bound value against the validate tags.
+func check(rv reflect.Value, path string, errs *BindError) {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			fieldPath := path
+			if !f.Anonymous || f.Tag.Get("toml") != "" {
+				fieldPath += "." + fieldName(f)
+			}
+			if rule := f.Tag.Get("validate"); rule != "" {
+				checkRule(rv.Field(i), rule, fieldPath, errs)
+			}
+			check(rv.Field(i), fieldPath, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			check(rv.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			check(iter.Value(), fmt.Sprintf("%s.%v", path, iter.Key()), errs)
+		}
+	}
+}
+
+func checkRule(rv reflect.Value, rule, path string, errs *BindError) {
+	for _, r := range strings.Split(rule, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(r), "=")
+		switch name {
+		case "required":
+			if rv.IsZero() {
+				errs.add(path, "is required")
+			}
+		case "min", "max":
+			val, limit, err := measure(rv, arg)
+			if err != nil {
+				errs.add(path, fmt.Sprintf("invalid %s rule: %v", name, err))
+			} else if name == "min" && val < limit {
+				errs.add(path, fmt.Sprintf("must be at least %s", arg))
+			} else if name == "max" && val > limit {
+				errs.add(path, fmt.Sprintf("must be at most %s", arg))
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			val := fmt.Sprint(rv.Interface())
+			found := false
+			for _, o := range options {
+				if o == val {
+					found = true
+					break
+				}
+			}
+			if !found {
+				errs.add(path, fmt.Sprintf("must be one of %s, got %q", strings.Join(options, ", "), val))
+			}
+		case "":
+		default:
+			errs.add(path, fmt.Sprintf("unknown rule %s", name))
+		}
+	}
+}
+
+// measure returns what min and max compare: the value of numbers, the length of anything else.
+func measure(rv reflect.Value, arg string) (float64, float64, error) {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return 0, 0, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Type() == durationType {
+		d, err := time.ParseDuration(arg)
+		return float64(rv.Int()), float64(d), err
+	}
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), limit, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), limit, nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), limit, nil
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return float64(rv.Len()), limit, nil
+	}
+	return 0, 0, fmt.Errorf("can't compare %s", rv.Type())
+}
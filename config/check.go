@@ -0,0 +1,53 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+type target struct {
+	node string
+	key  string
+	typ  reflect.Type
+}
+
+// CheckResult is the outcome of binding a registered target, Err is ErrNodeNotExists when it's not configured.
+type CheckResult struct {
+	Path string
+	Err  error
+}
+
+var targets struct {
+	lock sync.Mutex
+	list []target
+}
+
+// RegisterTarget records the struct obj points to as what node.key binds into, so that Check and
+// configctl check can validate the config without starting anything, e.g. config.RegisterTarget("db", "redis", &conf).
+func RegisterTarget(node, key string, obj interface{}) {
+	targets.lock.Lock()
+	defer targets.lock.Unlock()
+
+	targets.list = append(targets.list, target{node: node, key: key, typ: reflect.TypeOf(obj).Elem()})
+}
+
+// Check binds every registered target into a new value of its type, the results are sorted by path.
+func (conf *Config) Check() []CheckResult {
+	targets.lock.Lock()
+	list := targets.list
+	targets.lock.Unlock()
+
+	res := make([]CheckResult, 0, len(list))
+	for _, t := range list {
+		path := t.node
+		if t.key != "" {
+			path += "." + t.key
+		}
+		res = append(res, CheckResult{Path: path, Err: conf.Bind(t.node, t.key, reflect.New(t.typ).Interface())})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+	return res
+}
@@ -1,74 +1,156 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"github.com/BurntSushi/toml"
+	"github.com/holgerfy/go-pkg/app"
 	"github.com/holgerfy/go-pkg/log"
 	jsoniter "github.com/json-iterator/go"
-	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 )
 
+// Config is an immutable snapshot of the merged sources, see New and Load.
 type Config struct {
-	configs map[string]map[string]interface{}
-	once    sync.Once
+	configs   map[string]map[string]interface{}
+	opts      Options
+	sources   []Source
+	dirs      []string          // read by the Dir sources, watched by Watch
+	files     []string          // included by the files in dirs, watched by Watch
+	providers []Provider        // of the Remote sources, watched by Watch
+	origins   map[string]string // path: the file, env var, flag or remote key that set it
+	warnings  []string
+	secrets   map[string]string // path: resolved value
+}
+
+// Options of Load, zero values take the defaults.
+type Options struct {
+	Paths   []string // config dirs, later ones override earlier ones, default the config dir under app.Root()
+	Env     string   // selects the <node>.<env> overlays, default app.Env()
+	Environ []string // env vars checked for overrides, default os.Environ()
+	Args    []string // command line checked for --set overrides, default os.Args[1:]
+	KeyFile string   // key of enc:v1: values, see secret.go
+	Sources []Source // merged after the files and before the overrides, e.g. Remote(provider, opts)
+	// KeepSecrets leaves secret references and encrypted values as they are, for tools that only read the structure
+	KeepSecrets bool
 }
 
 var (
 	config           *Config
+	configLock       sync.RWMutex
 	json             = jsoniter.Config{EscapeHTML: true, TagKey: "toml"}.Froze()
 	ErrNodeNotExists = errors.New("node not exists")
+	ErrNotLoaded     = errors.New("config not loaded")
+	sensitiveWords   = []string{"password", "secret", "token", "credential", "private_key"}
 )
 
-func LoadConfig(path []string) {
-	fmt.Println(path)
-	config = new(Config).load(path)
+const redactedValue = "******"
+
+// LoadConfig loads the config files in path, the config dir under app.Root() if path is empty,
+// and makes them the instance returned by GetInstance.
+func LoadConfig(path []string) error {
+	return LoadInstance(Options{Paths: path})
+}
+
+// LoadInstance loads the config with opts and makes it the instance returned by GetInstance.
+func LoadInstance(opts Options) error {
+	conf, err := Load(opts)
+	if err != nil {
+		// the new config isn't built, mask the secrets it most likely shares with the current one
+		log.Logger().Error(context.Background(), "failed to load config, err: ", GetInstance().RedactString(err.Error()))
+		return err
+	}
+	for _, warning := range conf.Warnings() {
+		log.Logger().Warn(context.Background(), conf.RedactString(warning))
+	}
+	swap(conf)
+	return nil
+}
+
+// Load reads the config files and applies the overrides. Parse errors are returned as *ParseError.
+func Load(opts Options) (*Config, error) {
+	opts = opts.withDefaults()
+	sources := append([]Source{Dir(opts.Paths...)}, opts.Sources...)
+	return build(opts, append(sources, Overrides(opts.Environ, opts.Args)))
+}
+
+func (opts Options) withDefaults() Options {
+	if len(opts.Paths) == 0 {
+		opts.Paths = []string{filepath.Join(app.Root(), "config")}
+	}
+	if opts.Env == "" {
+		opts.Env = string(app.Env())
+	}
+	if opts.Environ == nil {
+		opts.Environ = os.Environ()
+	}
+	if opts.Args == nil {
+		opts.Args = os.Args[1:]
+	}
+	return opts
 }
 
+// GetInstance returns the config loaded by LoadConfig, nil before that.
 func GetInstance() *Config {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
 	return config
 }
 
-func (conf *Config) copy(node string, value map[string]interface{}) {
-	for key, val := range value {
-		if conf.configs[node] == nil {
-			conf.configs[node] = make(map[string]interface{})
-		}
-		conf.configs[node][key] = val
+func Component(path []string) *app.Component {
+	return &app.Component{
+		Name:      "config",
+		DependsOn: []string{"log"},
+		Start: func(ctx context.Context) error {
+			return LoadConfig(path)
+		},
 	}
 }
 
-func (conf *Config) load(path []string) *Config {
-	conf.once.Do(func() {
-		conf.configs = make(map[string]map[string]interface{})
-		for _, dir := range path {
-			rd, err := ioutil.ReadDir(dir)
-			if err != nil {
-				continue
-			}
-			for _, fi := range rd {
-				if !fi.IsDir() {
-					file := dir + "/" + fi.Name()
-					if strings.HasSuffix(file, ".toml") {
-						var config map[string]interface{}
-						if _, err = toml.DecodeFile(file, &config); err != nil {
-							log.Logger().Fatal(nil, "failed to load config", dir+fi.Name())
-						}
-						conf.copy(strings.TrimSuffix(fi.Name(), ".toml"), config)
-					}
-				}
-			}
-		}
-	})
-	return conf
+// Nodes returns the names of the nodes, sorted.
+func (conf *Config) Nodes() []string {
+	if conf == nil {
+		return nil
+	}
+	nodes := make([]string, 0, len(conf.configs))
+	for node := range conf.configs {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
 }
 
+// Warnings are the problems found while loading that did not stop it, like a node defined in several dirs.
+func (conf *Config) Warnings() []string {
+	if conf == nil {
+		return nil
+	}
+	return conf.warnings
+}
+
+func (conf *Config) merge(node string, value map[string]interface{}, origin string) {
+	if conf.configs[node] == nil {
+		conf.configs[node] = make(map[string]interface{})
+	}
+	mergeMap(conf.configs[node], value)
+	conf.record(node, value, origin)
+}
+
+// Bind decodes node.key, or the whole node if key is empty, into obj. Struct fields take
+// the default and validate tags into account, see bind.go. A missing node or key returns ErrNodeNotExists.
 func (conf *Config) Bind(node, key string, obj interface{}) error {
+	if conf == nil {
+		return ErrNotLoaded
+	}
 	nodeVal, ok := conf.configs[node]
 	if !ok {
-		return nil
+		return ErrNodeNotExists
 	}
 
 	var objVal interface{}
@@ -81,7 +163,11 @@ func (conf *Config) Bind(node, key string, obj interface{}) error {
 		objVal = nodeVal
 	}
 
-	return conf.assignment(objVal, obj)
+	path := node
+	if key != "" {
+		path += "." + key
+	}
+	return conf.assignment(path, objVal, obj)
 }
 
 func (conf *Config) GetChildConf(node, key, subKey string) (interface{}, error) {
@@ -96,7 +182,78 @@ func (conf *Config) GetChildConf(node, key, subKey string) (interface{}, error)
 	return val, nil
 }
 
-func (conf *Config) assignment(val, obj interface{}) error {
+// Redacted returns a copy of all nodes with secrets and the values of sensitive keys masked.
+func (conf *Config) Redacted() map[string]interface{} {
+	if conf == nil {
+		return nil
+	}
+	res := make(map[string]interface{}, len(conf.configs))
+	for node, val := range conf.configs {
+		res[node] = conf.redact(node, val)
+	}
+	return res
+}
+
+func (conf *Config) redact(path string, val interface{}) interface{} {
+	if _, ok := conf.secrets[path]; ok {
+		return redactedValue
+	}
+	switch v := val.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if isSensitive(key) {
+				res[key] = redactedValue
+			} else {
+				res[key] = conf.redact(path+"."+key, item)
+			}
+		}
+		return res
+	case []map[string]interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = conf.redact(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = conf.redact(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+		return res
+	}
+	return val
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range sensitiveWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (conf *Config) assignment(path string, val, obj interface{}) error {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		data, _ := json.Marshal(val)
+		return json.Unmarshal(data, obj)
+	}
+	// decoded into a new value, obj keeps no stale field and is left as it was when the bind fails
+	errs := &BindError{}
+	res := reflect.New(rv.Elem().Type())
+	val = prepare(val, rv.Type().Elem(), path, errs)
 	data, _ := json.Marshal(val)
-	return json.Unmarshal(data, obj)
+	if err := json.Unmarshal(data, res.Interface()); err != nil {
+		errs.add(path, err.Error())
+	} else {
+		check(res.Elem(), path, errs)
+	}
+	if len(errs.Fields) > 0 {
+		return errs
+	}
+	rv.Elem().Set(res.Elem())
+	return nil
 }
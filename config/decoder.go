@@ -0,0 +1,280 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder turns the content of a config file into the key tree of its node. Trees use the
+// TOML types: map[string]interface{}, []interface{}, string, int64, float64, bool and time.Time.
+type Decoder func(data []byte) (map[string]interface{}, error)
+
+var decoders = struct {
+	lock sync.RWMutex
+	exts map[string]Decoder
+}{exts: map[string]Decoder{
+	".toml": decodeToml,
+	".yaml": decodeYaml,
+	".yml":  decodeYaml,
+	".json": decodeJson,
+	".env":  decodeDotenv,
+}}
+
+// RegisterDecoder adds or replaces the decoder of files with the extension ext, e.g. ".ini".
+func RegisterDecoder(ext string, dec Decoder) {
+	decoders.lock.Lock()
+	defer decoders.lock.Unlock()
+
+	decoders.exts[ext] = dec
+}
+
+func getDecoder(name string) (Decoder, string, bool) {
+	decoders.lock.RLock()
+	defer decoders.lock.RUnlock()
+
+	ext := filepath.Ext(name)
+	dec, ok := decoders.exts[ext]
+	return dec, ext, ok
+}
+
+// ParseError is a config file that failed to decode, Line and Column are 0 when the decoder doesn't tell.
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+	if e.Column == 0 {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+):`)
+
+func decodeFile(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, &ParseError{File: path, Err: err}
+	}
+	return decode(path, data)
+}
+
+// decode picks the decoder by the extension of path, which only names the data in errors.
+func decode(path string, data []byte) (map[string]interface{}, error) {
+	dec, _, ok := getDecoder(path)
+	if !ok {
+		return nil, &ParseError{File: path, Err: errors.New("no decoder for this file type")}
+	}
+	tree, err := dec(data)
+	if err != nil {
+		return nil, position(path, data, err)
+	}
+	if tree == nil {
+		tree = make(map[string]interface{})
+	}
+	return tree, nil
+}
+
+// position finds where the decoders reported the error.
+func position(path string, data []byte, err error) *ParseError {
+	pe := &ParseError{File: path, Err: err}
+	var tomlErr toml.ParseError
+	var jsonErr *stdjson.SyntaxError
+	var jsonTypeErr *stdjson.UnmarshalTypeError
+	var parseErr *ParseError
+	switch {
+	case errors.As(err, &tomlErr):
+		pe.Line = tomlErr.Position.Line
+		if tomlErr.Position.Start > 0 {
+			_, pe.Column = lineColumn(data, tomlErr.Position.Start)
+		}
+		pe.Err = errors.New(tomlErr.Message)
+		if tomlErr.Message == "" {
+			pe.Err = err
+		}
+	case errors.As(err, &jsonErr):
+		// the offset is past the offending byte
+		pe.Line, pe.Column = lineColumn(data, int(jsonErr.Offset)-1)
+	case errors.As(err, &jsonTypeErr):
+		pe.Line, pe.Column = lineColumn(data, int(jsonTypeErr.Offset))
+	case errors.As(err, &parseErr):
+		pe.Line, pe.Column, pe.Err = parseErr.Line, parseErr.Column, parseErr.Err
+	default:
+		if m := yamlLine.FindStringSubmatch(err.Error()); m != nil {
+			pe.Line, _ = strconv.Atoi(m[1])
+		}
+	}
+	return pe
+}
+
+// lineColumn converts a byte offset into a 1-based line and column.
+func lineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+func decodeToml(data []byte) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	if _, err := toml.Decode(string(data), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func decodeYaml(data []byte) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	if err := yaml.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return normalize(res).(map[string]interface{}), nil
+}
+
+func decodeJson(data []byte) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	dec := stdjson.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&res); err != nil {
+		return nil, err
+	}
+	return normalize(res).(map[string]interface{}), nil
+}
+
+// decodeDotenv reads KEY=VALUE lines. Keys are lower cased and "__" nests them like the
+// env overrides do, REDIS__ADDR=redis:6379 becomes redis.addr.
+func decodeDotenv(data []byte) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, &ParseError{Line: line, Err: errors.New("expected KEY=VALUE")}
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		var val interface{}
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, &ParseError{Line: line, Err: err}
+			}
+			val = unquoted
+		case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) > 1:
+			val = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			val = dotenvValue(value)
+		}
+		path := strings.Split(strings.ToLower(key), "__")
+		m := res
+		for _, k := range path[:len(path)-1] {
+			child, ok := m[k].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				m[k] = child
+			}
+			m = child
+		}
+		m[path[len(path)-1]] = val
+	}
+	return res, scanner.Err()
+}
+
+// dotenvValue converts an unquoted value to a number, bool or array only when it reads back
+// the same, VERSION=1.10 stays the string "1.10".
+func dotenvValue(value string) interface{} {
+	val, ok := literal(value)
+	if !ok {
+		return value
+	}
+	var s string
+	switch v := val.(type) {
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(v)
+	case []interface{}:
+		return v
+	default:
+		return value
+	}
+	if s != value {
+		return value
+	}
+	return val
+}
+
+// normalize converts decoded YAML and JSON values to the types the TOML decoder produces.
+func normalize(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			res[fmt.Sprint(key)] = normalize(item)
+		}
+		return res
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case stdjson.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case int:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case nil:
+		return nil
+	}
+	return val
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Paths address nested values from the node down, with array indices as segments or in
+// brackets: "db.mongo.url", "db.mongo.hosts.0" or "db.mongo.hosts[0]". The getters return
+// the optional default, or the zero value, when the path is missing or can't be converted.
+
+func splitPath(path string) []string {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	return strings.Split(path, ".")
+}
+
+// Get returns a copy of the raw value at path.
+func (conf *Config) Get(path string) (interface{}, bool) {
+	if conf == nil {
+		return nil, false
+	}
+	segments := splitPath(path)
+	nodeVal, ok := conf.configs[segments[0]]
+	if !ok {
+		return nil, false
+	}
+	val, _, ok := walk(nodeVal, segments[1:])
+	if !ok {
+		return nil, false
+	}
+	return copyValue(val), true
+}
+
+// walk follows segments down from val. It returns the value and the segments it followed,
+// with the key names as they are in the tree, e.g. replicaSet for replicaset.
+func walk(val interface{}, segments []string) (interface{}, []string, bool) {
+	keys := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			key := findKey(v, segment)
+			item, ok := v[key]
+			if !ok {
+				return nil, keys, false
+			}
+			val, segment = item, key
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, keys, false
+			}
+			val = v[i]
+		case []map[string]interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, keys, false
+			}
+			val = v[i]
+		default:
+			return nil, keys, false
+		}
+		keys = append(keys, segment)
+	}
+	return val, keys, true
+}
+
+func (conf *Config) IsSet(path string) bool {
+	_, ok := conf.Get(path)
+	return ok
+}
+
+func (conf *Config) GetString(path string, def ...string) string {
+	switch v := conf.raw(path).(type) {
+	case string:
+		return v
+	case int64, float64, bool:
+		return fmt.Sprint(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return first(def)
+}
+
+func (conf *Config) GetInt(path string, def ...int) int {
+	switch v := conf.raw(path).(type) {
+	case int64:
+		return int(v)
+	case float64:
+		if v == float64(int(v)) {
+			return int(v)
+		}
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return first(def)
+}
+
+func (conf *Config) GetFloat(path string, def ...float64) float64 {
+	switch v := conf.raw(path).(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return first(def)
+}
+
+func (conf *Config) GetBool(path string, def ...bool) bool {
+	switch v := conf.raw(path).(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return first(def)
+}
+
+// GetDuration reads strings like "5s", integers are nanoseconds like in Bind.
+func (conf *Config) GetDuration(path string, def ...time.Duration) time.Duration {
+	switch v := conf.raw(path).(type) {
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	case int64:
+		return time.Duration(v)
+	}
+	return first(def)
+}
+
+func (conf *Config) GetStringSlice(path string, def ...[]string) []string {
+	items, ok := conf.raw(path).([]interface{})
+	if !ok {
+		return first(def)
+	}
+	res := make([]string, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			res = append(res, v)
+		case int64, float64, bool:
+			res = append(res, fmt.Sprint(v))
+		default:
+			return first(def)
+		}
+	}
+	return res
+}
+
+func (conf *Config) GetStringMap(path string, def ...map[string]interface{}) map[string]interface{} {
+	if m, ok := conf.raw(path).(map[string]interface{}); ok {
+		return m
+	}
+	return first(def)
+}
+
+func (conf *Config) raw(path string) interface{} {
+	val, _ := conf.Get(path)
+	return val
+}
+
+func first[T any](def []T) T {
+	var zero T
+	if len(def) > 0 {
+		return def[0]
+	}
+	return zero
+}
+
+// The package level getters read the instance returned by GetInstance.
+
+func Get(path string) (interface{}, bool) {
+	return GetInstance().Get(path)
+}
+
+func IsSet(path string) bool {
+	return GetInstance().IsSet(path)
+}
+
+func GetString(path string, def ...string) string {
+	return GetInstance().GetString(path, def...)
+}
+
+func GetInt(path string, def ...int) int {
+	return GetInstance().GetInt(path, def...)
+}
+
+func GetFloat(path string, def ...float64) float64 {
+	return GetInstance().GetFloat(path, def...)
+}
+
+func GetBool(path string, def ...bool) bool {
+	return GetInstance().GetBool(path, def...)
+}
+
+func GetDuration(path string, def ...time.Duration) time.Duration {
+	return GetInstance().GetDuration(path, def...)
+}
+
+func GetStringSlice(path string, def ...[]string) []string {
+	return GetInstance().GetStringSlice(path, def...)
+}
+
+func GetStringMap(path string, def ...map[string]interface{}) map[string]interface{} {
+	return GetInstance().GetStringMap(path, def...)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IncludeKey lists the fragments a config file pulls in, as glob patterns relative to the file:
+// include = ["mongo/*.toml"]. The fragments are merged first, in the order of the patterns and then
+// by name, and the keys of the including file on top of them. Fragments can include others.
+const IncludeKey = "include"
+
+var ErrIncludeCycle = errors.New("include cycle")
+
+// part is the tree decoded from one file, kept apart to know where each value came from.
+type part struct {
+	path string
+	tree map[string]interface{}
+}
+
+// expand decodes path and, before it, the fragments it includes. stack holds the files including path.
+func expand(path string, stack []string) ([]part, error) {
+	for i, p := range stack {
+		if p == path {
+			chain := append(append([]string{}, stack[i:]...), path)
+			return nil, &ParseError{File: path, Err: fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(chain, " -> "))}
+		}
+	}
+	tree, err := decodeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := includes(tree)
+	if err != nil {
+		return nil, &ParseError{File: path, Err: err}
+	}
+	delete(tree, IncludeKey)
+
+	var parts []part
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, &ParseError{File: path, Err: fmt.Errorf("include %s: %w", pattern, err)}
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, `*?[\`) {
+			return nil, &ParseError{File: path, Err: fmt.Errorf("include %s: %w", pattern, os.ErrNotExist)}
+		}
+		for _, match := range matches {
+			if fi, err := os.Stat(match); err != nil || fi.IsDir() {
+				continue
+			}
+			included, err := expand(match, append(stack, path))
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, included...)
+		}
+	}
+	return append(parts, part{path: path, tree: tree}), nil
+}
+
+func includes(tree map[string]interface{}) ([]string, error) {
+	switch v := tree[IncludeKey].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		patterns := make([]string, 0, len(v))
+		for _, item := range v {
+			pattern, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of paths", IncludeKey)
+			}
+			patterns = append(patterns, pattern)
+		}
+		return patterns, nil
+	}
+	return nil, fmt.Errorf("%s must be a list of paths", IncludeKey)
+}
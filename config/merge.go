@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/holgerfy/go-pkg/app"
+)
+
+// Layers of a node, merged in this order: db.toml, db.<RUN_ENV>.toml, db.local.toml.
+// Any extension with a registered decoder works the same way. A file named just .env holds
+// several nodes, the first segment of its keys, and is merged with the base files. A directory is a node split
+// by key: db/redis.toml is the redis key of node db, with the overlays db/redis.<RUN_ENV>.toml
+// and db/redis.local.toml, merged after db.toml in the same layer.
+const (
+	layerBase = iota
+	layerEnv
+	layerLocal
+)
+
+const localLayer = "local"
+
+type file struct {
+	path  string
+	node  string
+	key   string // set for the files of a node dir
+	layer int
+	dir   int
+}
+
+// listFiles returns the config files of the dirs in merge order: by layer first, then by
+// the order of the dirs, then by file name. Overlays of other environments are skipped.
+func listFiles(path []string, env string) ([]file, error) {
+	var files []file
+	for i, dir := range path {
+		rd, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config dir: %w", err)
+		}
+		for _, fi := range rd {
+			if !fi.IsDir() {
+				if f, ok := configFile(dir, fi.Name(), env); ok {
+					f.dir = i
+					files = append(files, f)
+				}
+				continue
+			}
+			if strings.HasPrefix(fi.Name(), ".") {
+				continue
+			}
+			nodeDir := filepath.Join(dir, fi.Name())
+			sub, err := ioutil.ReadDir(nodeDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read config dir: %w", err)
+			}
+			for _, sfi := range sub {
+				if sfi.IsDir() {
+					continue
+				}
+				if f, ok := configFile(nodeDir, sfi.Name(), env); ok {
+					f.node, f.key, f.dir = fi.Name(), f.node, i
+					files = append(files, f)
+				}
+			}
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].layer != files[j].layer {
+			return files[i].layer < files[j].layer
+		}
+		if files[i].dir != files[j].dir {
+			return files[i].dir < files[j].dir
+		}
+		return files[i].path < files[j].path
+	})
+	return files, nil
+}
+
+// configFile returns the file named name in dir if it's a config file of env.
+func configFile(dir, name, env string) (file, bool) {
+	_, ext, ok := getDecoder(name)
+	if !ok {
+		return file{}, false
+	}
+	if name == ext {
+		// a bare .env, e.g. mounted by Kubernetes, names the node in its keys: DB__REDIS__ADDR
+		if ext != ".env" {
+			return file{}, false
+		}
+		return file{path: filepath.Join(dir, name), layer: layerBase}, true
+	}
+	node, overlay := splitName(strings.TrimSuffix(name, ext), env)
+	f := file{path: filepath.Join(dir, name), node: node}
+	switch overlay {
+	case "":
+		f.layer = layerBase
+	case localLayer:
+		f.layer = layerLocal
+	case env:
+		f.layer = layerEnv
+	default:
+		return file{}, false
+	}
+	return f, true
+}
+
+// duplicateNodes warns about nodes with a base file in more than one place, e.g. conf/db.toml
+// and shared/db.toml, or db.toml and db.yaml. They are merged, which is rarely intended.
+func duplicateNodes(files []file) []string {
+	bases := make(map[string][]string)
+	var nodes []string
+	for _, f := range files {
+		if f.layer != layerBase || f.node == "" {
+			continue
+		}
+		node := f.node
+		if f.key != "" {
+			node += "." + f.key
+		}
+		if _, ok := bases[node]; !ok {
+			nodes = append(nodes, node)
+		}
+		bases[node] = append(bases[node], f.path)
+	}
+	var warnings []string
+	for _, node := range nodes {
+		if len(bases[node]) > 1 {
+			warnings = append(warnings, fmt.Sprintf("node %s is defined in several files, they are merged in this order: %s",
+				node, strings.Join(bases[node], ", ")))
+		}
+	}
+	return warnings
+}
+
+// splitName splits "db.dev" into the node "db" and the overlay "dev". A suffix that is neither
+// local, env nor a known environment is part of the node, app.v2.toml is the node app.v2.
+func splitName(name, env string) (string, string) {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return name, ""
+	}
+	overlay := name[i+1:]
+	if overlay != localLayer && overlay != env {
+		if _, err := app.ParseEnv(overlay); err != nil {
+			return name, ""
+		}
+	}
+	return name[:i], overlay
+}
+
+// mergeMap deep merges src into dst. Tables are merged key by key at any depth,
+// anything else, arrays included, is replaced by the value from src.
+func mergeMap(dst, src map[string]interface{}) {
+	for key, val := range src {
+		srcMap, srcOk := val.(map[string]interface{})
+		dstMap, dstOk := dst[key].(map[string]interface{})
+		if srcOk && dstOk {
+			mergeMap(dstMap, srcMap)
+			continue
+		}
+		dst[key] = copyValue(val)
+	}
+}
+
+func copyValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			res[key] = copyValue(item)
+		}
+		return res
+	case []map[string]interface{}:
+		res := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			res[i] = copyValue(item).(map[string]interface{})
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = copyValue(item)
+		}
+		return res
+	}
+	return val
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Entry is a value of the redacted config with where it was set.
+type Entry struct {
+	Path   string
+	Value  interface{}
+	Origin string
+}
+
+// record sets origin as the source of val merged at path. Tables are merged, so only the keys
+// in val change their origin, anything else replaces what was at path and below.
+func (conf *Config) record(path string, val interface{}, origin string) {
+	conf.origins[path] = origin
+	if m, ok := val.(map[string]interface{}); ok {
+		for key, item := range m {
+			conf.record(path+"."+key, item, origin)
+		}
+		return
+	}
+	for p := range conf.origins {
+		if strings.HasPrefix(p, path+".") {
+			delete(conf.origins, p)
+		}
+	}
+}
+
+// Origin returns where the value at path was set: the file, "env NAME", "flag --set key",
+// "remote key k" or "map node", empty if it's not set.
+func (conf *Config) Origin(path string) string {
+	if conf == nil {
+		return ""
+	}
+	segments := splitPath(path)
+	node := findKey(conf.nodes(), segments[0])
+	nodeVal, ok := conf.configs[node]
+	if !ok {
+		return ""
+	}
+	_, keys, _ := walk(nodeVal, segments[1:])
+	keys = append([]string{node}, keys...)
+	for i := len(keys); i > 0; i-- {
+		if origin, ok := conf.origins[strings.Join(keys[:i], ".")]; ok {
+			return origin
+		}
+	}
+	return ""
+}
+
+// Entries lists the values of Redacted sorted by path, with tables and arrays flattened to their items.
+func (conf *Config) Entries() []Entry {
+	var res []Entry
+	var add func(path string, val interface{})
+	add = func(path string, val interface{}) {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			if len(v) > 0 {
+				for key, item := range v {
+					add(path+"."+key, item)
+				}
+				return
+			}
+		case []interface{}:
+			if len(v) > 0 {
+				for i, item := range v {
+					add(fmt.Sprintf("%s.%d", path, i), item)
+				}
+				return
+			}
+		}
+		res = append(res, Entry{Path: path, Value: val, Origin: conf.Origin(path)})
+	}
+	for node, tree := range conf.Redacted() {
+		add(node, tree)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+	return res
+}
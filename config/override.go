@@ -0,0 +1,218 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// EnvPrefix marks env vars that override config keys, "__" separates the path:
+// APP__DB__REDIS__ADDR=redis:6379 sets db.redis.addr.
+const EnvPrefix = "APP__"
+
+// SetFlag overrides a config key on the command line: --set db.mongo.max_pool_size=50.
+const SetFlag = "set"
+
+type override struct {
+	path   []string
+	value  string
+	source string
+}
+
+func envOverrides(environ []string) []override {
+	var res []override
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, EnvPrefix) {
+			continue
+		}
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "__")
+		if len(path) < 2 {
+			continue
+		}
+		res = append(res, override{path: path, value: value, source: "env " + name})
+	}
+	return res
+}
+
+func flagOverrides(args []string) []override {
+	var res []override
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		var kv string
+		if name == SetFlag && i+1 < len(args) {
+			i++
+			kv = args[i]
+		} else if strings.HasPrefix(name, SetFlag+"=") {
+			kv = strings.TrimPrefix(name, SetFlag+"=")
+		} else {
+			continue
+		}
+		key, value, ok := strings.Cut(kv, "=")
+		path := strings.Split(key, ".")
+		if !ok || len(path) < 2 {
+			continue
+		}
+		res = append(res, override{path: path, value: value, source: "flag --set " + key})
+	}
+	return res
+}
+
+// applyOverrides sets each override, coercing the value to the type of the value it replaces.
+func (conf *Config) applyOverrides(overrides []override) error {
+	for _, o := range overrides {
+		node := findKey(conf.nodes(), o.path[0])
+		if conf.configs[node] == nil {
+			conf.configs[node] = make(map[string]interface{})
+		}
+		if err := setPath(conf.configs[node], o.path[1:], o.value); err != nil {
+			return fmt.Errorf("%s: %s: %w", o.source, strings.Join(o.path, "."), err)
+		}
+		_, keys, _ := walk(conf.configs[node], o.path[1:])
+		conf.record(strings.Join(append([]string{node}, keys...), "."), nil, o.source)
+	}
+	return nil
+}
+
+func (conf *Config) nodes() map[string]interface{} {
+	nodes := make(map[string]interface{}, len(conf.configs))
+	for node := range conf.configs {
+		nodes[node] = nil
+	}
+	return nodes
+}
+
+// findKey matches env var paths, which are lower case, against keys like replicaSet.
+func findKey(m map[string]interface{}, key string) string {
+	if _, ok := m[key]; ok {
+		return key
+	}
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return key
+}
+
+func setPath(m map[string]interface{}, path []string, value string) error {
+	key := findKey(m, path[0])
+	if len(path) > 1 {
+		switch child := m[key].(type) {
+		case map[string]interface{}:
+			return setPath(child, path[1:], value)
+		case []map[string]interface{}:
+			i, err := index(path[1], len(child))
+			if err != nil {
+				return err
+			}
+			if len(path) == 2 {
+				return fmt.Errorf("can't override table %s with a value", path[1])
+			}
+			return setPath(child[i], path[2:], value)
+		case []interface{}:
+			i, err := index(path[1], len(child))
+			if err != nil {
+				return err
+			}
+			if len(path) == 2 {
+				child[i], err = coerce(child[i], value)
+				return err
+			}
+			if sub, ok := child[i].(map[string]interface{}); ok {
+				return setPath(sub, path[2:], value)
+			}
+			return fmt.Errorf("%s is not a table", path[1])
+		case nil:
+			table := make(map[string]interface{})
+			m[key] = table
+			return setPath(table, path[1:], value)
+		default:
+			return fmt.Errorf("%s is not a table", key)
+		}
+	}
+	val, err := coerce(m[key], value)
+	if err != nil {
+		return err
+	}
+	m[key] = val
+	return nil
+}
+
+func index(s string, length int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 || i >= length {
+		return 0, fmt.Errorf("invalid index %s", s)
+	}
+	return i, nil
+}
+
+// coerce converts value to the type of old. A new key gets the type of value read as
+// a TOML literal, or stays a string.
+func coerce(old interface{}, value string) (interface{}, error) {
+	switch old := old.(type) {
+	case string:
+		return value, nil
+	case int64:
+		return strconv.ParseInt(value, 10, 64)
+	case float64:
+		return strconv.ParseFloat(value, 64)
+	case bool:
+		return strconv.ParseBool(value)
+	case time.Time:
+		return time.Parse(time.RFC3339, value)
+	case []interface{}:
+		if val, ok := literal(value); ok {
+			if arr, ok := val.([]interface{}); ok {
+				return arr, nil
+			}
+		}
+		items := strings.Split(value, ",")
+		res := make([]interface{}, len(items))
+		for i, item := range items {
+			var elem interface{} = ""
+			if len(old) > 0 {
+				elem = old[0]
+			}
+			v, err := coerce(elem, strings.TrimSpace(item))
+			if err != nil {
+				return nil, err
+			}
+			res[i] = v
+		}
+		return res, nil
+	case map[string]interface{}, []map[string]interface{}:
+		val, ok := literal(value)
+		if !ok {
+			return nil, fmt.Errorf("can't override a table with %q", value)
+		}
+		return val, nil
+	case nil:
+		if val, ok := literal(value); ok {
+			return val, nil
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", old)
+}
+
+func literal(value string) (interface{}, bool) {
+	var doc map[string]interface{}
+	if _, err := toml.Decode("v = "+value, &doc); err != nil {
+		return nil, false
+	}
+	return doc["v"], true
+}
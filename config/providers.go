@@ -0,0 +1,155 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// MemoryProvider is an in-process Provider, for tests and for values computed by the program.
+type MemoryProvider struct {
+	lock     sync.Mutex
+	values   map[string]string
+	err      error
+	watchers map[chan struct{}]struct{}
+}
+
+func NewMemoryProvider(values map[string]string) *MemoryProvider {
+	p := &MemoryProvider{values: make(map[string]string), watchers: make(map[chan struct{}]struct{})}
+	for key, val := range values {
+		p.values[key] = val
+	}
+	return p
+}
+
+func (p *MemoryProvider) Load(ctx context.Context) (map[string]string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.err != nil {
+		return nil, p.err
+	}
+	res := make(map[string]string, len(p.values))
+	for key, val := range p.values {
+		res[key] = val
+	}
+	return res, nil
+}
+
+func (p *MemoryProvider) Watch(ctx context.Context, changed func()) error {
+	ch := make(chan struct{}, 1)
+	p.lock.Lock()
+	p.watchers[ch] = struct{}{}
+	p.lock.Unlock()
+	defer func() {
+		p.lock.Lock()
+		delete(p.watchers, ch)
+		p.lock.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ch:
+			changed()
+		}
+	}
+}
+
+func (p *MemoryProvider) Set(key, value string) {
+	p.update(func() { p.values[key] = value })
+}
+
+func (p *MemoryProvider) Delete(key string) {
+	p.update(func() { delete(p.values, key) })
+}
+
+// Fail makes Load return err until it's called with nil, like a store that went down.
+func (p *MemoryProvider) Fail(err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.err = err
+}
+
+func (p *MemoryProvider) update(fn func()) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	fn()
+	for ch := range p.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// HTTPProvider reads a config service that answers a GET of URL with a JSON object of keys and string values.
+type HTTPProvider struct {
+	URL      string
+	Client   *http.Client  // default http.DefaultClient
+	Header   http.Header   // added to the requests, e.g. an auth token
+	Interval time.Duration // Watch polls every Interval, default 30s
+}
+
+const defaultPollInterval = 30 * time.Second
+
+func (p *HTTPProvider) Load(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, vals := range p.Header {
+		req.Header[key] = vals
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("config service %s: %s", p.URL, resp.Status)
+	}
+	var values map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&values); err != nil {
+		return nil, fmt.Errorf("config service %s: %w", p.URL, err)
+	}
+	if values == nil {
+		return nil, errors.New("config service " + p.URL + ": no values")
+	}
+	return values, nil
+}
+
+// Watch polls the service and calls changed when the values differ from the last ones it read.
+func (p *HTTPProvider) Watch(ctx context.Context, changed func()) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	last, _ := p.Load(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		values, err := p.Load(ctx)
+		if err != nil || reflect.DeepEqual(values, last) {
+			continue
+		}
+		last = values
+		changed()
+	}
+}
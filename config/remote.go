@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Provider is a remote store of config values, like a key-value store or a config service.
+// Keys are "/" separated paths from the node down, "db/redis/addr" sets db.redis.addr and its
+// value is coerced like an override. A key named like a config file, "db.toml" or "db/mongo.yaml",
+// holds a whole document decoded by its extension and merged at its path.
+type Provider interface {
+	// Load returns all the keys and their values.
+	Load(ctx context.Context) (map[string]string, error)
+	// Watch blocks until ctx is done and calls changed whenever the values may have changed.
+	Watch(ctx context.Context, changed func()) error
+}
+
+// RemoteOptions of Remote, zero values take the defaults.
+type RemoteOptions struct {
+	Prefix  string        // only the keys under it are read, with it stripped, e.g. "services/orders/"
+	Cache   string        // file keeping the last values loaded, read when the provider fails
+	Timeout time.Duration // of a load, default 5s
+}
+
+const defaultRemoteTimeout = 5 * time.Second
+
+// Remote merges the values of p. Its place among the sources sets its priority, with
+// New(Dir(path), Remote(p, opts)) the remote values override the files, the other way round they don't.
+// Watch reloads the config whenever p reports a change.
+func Remote(p Provider, opts RemoteOptions) Source {
+	return sourceFunc(func(conf *Config) error {
+		conf.providers = append(conf.providers, p)
+		values, err := loadRemote(p, opts)
+		switch {
+		case err != nil && opts.Cache == "":
+			return fmt.Errorf("failed to load remote config: %w", err)
+		case err != nil:
+			cached, cacheErr := readCache(opts.Cache)
+			if cacheErr != nil {
+				return fmt.Errorf("failed to load remote config: %w, cache: %v", err, cacheErr)
+			}
+			conf.warnings = append(conf.warnings, fmt.Sprintf("failed to load remote config, using the cache %s, err: %v", opts.Cache, err))
+			values = cached
+		case opts.Cache != "":
+			if err := writeCache(opts.Cache, values); err != nil {
+				conf.warnings = append(conf.warnings, fmt.Sprintf("failed to write the remote config cache, err: %v", err))
+			}
+		}
+		return conf.mergeRemote(values)
+	})
+}
+
+func loadRemote(p Provider, opts RemoteOptions) (map[string]string, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultRemoteTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	values, err := p.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(values))
+	for key, val := range values {
+		if strings.HasPrefix(key, opts.Prefix) {
+			res[strings.TrimPrefix(key, opts.Prefix)] = val
+		}
+	}
+	return res, nil
+}
+
+// mergeRemote merges the documents first, then sets the single values on top of them.
+func (conf *Config) mergeRemote(values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var overrides []override
+	for _, key := range keys {
+		path := strings.Split(strings.Trim(key, "/"), "/")
+		name := path[len(path)-1]
+		if _, _, ok := getDecoder(name); ok {
+			tree, err := decode(key, []byte(values[key]))
+			if err != nil {
+				return err
+			}
+			path[len(path)-1] = strings.TrimSuffix(name, filepath.Ext(name))
+			for i := len(path) - 1; i > 0; i-- {
+				tree = map[string]interface{}{path[i]: tree}
+			}
+			conf.merge(path[0], tree, "remote key "+key)
+			continue
+		}
+		if len(path) < 2 {
+			conf.warnings = append(conf.warnings, fmt.Sprintf("remote key %s is not under a node, ignored", key))
+			continue
+		}
+		overrides = append(overrides, override{path: path, value: values[key], source: "remote key " + key})
+	}
+	if err := conf.applyOverrides(overrides); err != nil {
+		return fmt.Errorf("failed to apply remote config: %w", err)
+	}
+	return nil
+}
+
+func readCache(file string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// writeCache replaces the cache atomically, readable by the owner only as it may hold secrets.
+func writeCache(file string, values map[string]string) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/holgerfy/go-pkg/app"
+)
+
+// Secret values in config files:
+//
+//	password = "${env:MONGO_PASSWORD}"
+//	password = "${file:/run/secrets/mongo}"
+//	password = "enc:v1:<base64 of nonce and AES-GCM ciphertext>"
+//
+// References can be part of a longer string, encrypted values can't. The key of encrypted values
+// is read from Options.KeyFile, APP_CONFIG_KEYFILE or config.key under app.Root(), as raw or
+// base64 encoded 16, 24 or 32 bytes. Values resolved this way are masked by Redacted.
+
+const (
+	KeyFileEnv = "APP_CONFIG_KEYFILE"
+	encPrefix  = "enc:v1:"
+)
+
+var (
+	ErrNoKey   = errors.New("no config key")
+	secretRefs = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+)
+
+type resolver struct {
+	opts    Options
+	env     map[string]string
+	key     []byte
+	secrets map[string]string
+}
+
+// resolveSecrets replaces the secret references and encrypted values in all nodes and
+// records the paths of the values it resolved.
+func (conf *Config) resolveSecrets() error {
+	r := &resolver{opts: conf.opts, env: make(map[string]string), secrets: make(map[string]string)}
+	for _, kv := range conf.opts.Environ {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			r.env[name] = value
+		}
+	}
+	for node, tree := range conf.configs {
+		if _, err := r.resolve(node, tree); err != nil {
+			return err
+		}
+	}
+	conf.secrets = r.secrets
+	return nil
+}
+
+func (r *resolver) resolve(path string, val interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			res, err := r.resolve(path+"."+key, item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = res
+		}
+	case []map[string]interface{}:
+		for i, item := range v {
+			if _, err := r.resolve(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			res, err := r.resolve(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = res
+		}
+	case string:
+		res, secret, err := r.resolveString(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if secret {
+			r.secrets[path] = res
+		}
+		return res, nil
+	}
+	return val, nil
+}
+
+func (r *resolver) resolveString(s string) (string, bool, error) {
+	if strings.HasPrefix(s, encPrefix) {
+		if r.key == nil {
+			key, err := ReadKeyFile(r.keyFile())
+			if err != nil {
+				return "", false, err
+			}
+			r.key = key
+		}
+		plain, err := Decrypt(r.key, s)
+		return plain, true, err
+	}
+	if !strings.Contains(s, "${") {
+		return s, false, nil
+	}
+	var resErr error
+	res := secretRefs.ReplaceAllStringFunc(s, func(ref string) string {
+		m := secretRefs.FindStringSubmatch(ref)
+		switch m[1] {
+		case "env":
+			val, ok := r.env[m[2]]
+			if !ok {
+				resErr = fmt.Errorf("env %s is not set", m[2])
+			}
+			return val
+		default:
+			file := m[2]
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(app.Root(), file)
+			}
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				resErr = err
+			}
+			return strings.TrimRight(string(data), "\r\n")
+		}
+	})
+	return res, res != s || resErr != nil, resErr
+}
+
+func (r *resolver) keyFile() string {
+	if r.opts.KeyFile != "" {
+		return r.opts.KeyFile
+	}
+	if file, ok := r.env[KeyFileEnv]; ok && file != "" {
+		return file
+	}
+	return filepath.Join(app.Root(), "config.key")
+}
+
+// ReadKeyFile reads an AES key stored raw or base64 encoded.
+func ReadKeyFile(file string) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoKey, err)
+	}
+	text := strings.TrimSpace(string(data))
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && validKeySize(len(key)) {
+		return key, nil
+	}
+	if validKeySize(len(data)) {
+		return data, nil
+	}
+	return nil, fmt.Errorf("%w: %s must hold 16, 24 or 32 bytes", ErrNoKey, file)
+}
+
+func validKeySize(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
+
+// GenerateKey returns a random base64 encoded 32 byte key for a key file.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Encrypt returns plaintext as an enc:v1: config value.
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func Decrypt(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// RedactString masks the resolved secrets wherever they appear in s, e.g. in a log message.
+// A nil config masks nothing.
+func (conf *Config) RedactString(s string) string {
+	if conf == nil {
+		return s
+	}
+	for _, secret := range conf.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redactedValue)
+		}
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"github.com/holgerfy/go-pkg/app"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Source adds values to a config built by New, sources later in the list override earlier ones.
+type Source interface {
+	load(conf *Config) error
+}
+
+type sourceFunc func(conf *Config) error
+
+func (fn sourceFunc) load(conf *Config) error {
+	return fn(conf)
+}
+
+// Dir reads the config files in paths, see merge.go for the file names and overlays and include.go
+// for includes. A file included by another one isn't loaded again for the node dir it's in.
+func Dir(paths ...string) Source {
+	return sourceFunc(func(conf *Config) error {
+		files, err := listFiles(paths, conf.opts.Env)
+		if err != nil {
+			return err
+		}
+		conf.dirs = append(conf.dirs, paths...)
+		parts := make([][]part, len(files))
+		included := make(map[string]bool)
+		for i, f := range files {
+			if parts[i], err = expand(f.path, nil); err != nil {
+				return err
+			}
+			for _, p := range parts[i][:len(parts[i])-1] {
+				included[p.path] = true
+				conf.files = append(conf.files, p.path)
+			}
+		}
+		var loaded []file
+		for i, f := range files {
+			if included[f.path] {
+				continue
+			}
+			loaded = append(loaded, f)
+			for _, p := range parts[i] {
+				if f.node == "" {
+					conf.mergeNodes(p.tree, p.path)
+					continue
+				}
+				tree := p.tree
+				if f.key != "" {
+					tree = map[string]interface{}{f.key: tree}
+				}
+				conf.merge(f.node, tree, p.path)
+			}
+		}
+		conf.warnings = append(conf.warnings, duplicateNodes(loaded)...)
+		return nil
+	})
+}
+
+// mergeNodes merges the tables of tree into the nodes named by their keys, for a bare .env file.
+func (conf *Config) mergeNodes(tree map[string]interface{}, origin string) {
+	nodes := make([]string, 0, len(tree))
+	for node := range tree {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		table, ok := tree[node].(map[string]interface{})
+		if !ok {
+			conf.warnings = append(conf.warnings, fmt.Sprintf("%s: %s has no node, use NODE__KEY", origin, strings.ToUpper(node)))
+			continue
+		}
+		conf.merge(node, table, origin)
+	}
+}
+
+// Map merges tree into node, mostly for tests and values computed by the program.
+func Map(node string, tree map[string]interface{}) Source {
+	return sourceFunc(func(conf *Config) error {
+		conf.merge(node, tree, "map "+node)
+		return nil
+	})
+}
+
+// Overrides applies the APP__ env vars in environ and the --set flags in args, see override.go.
+func Overrides(environ, args []string) Source {
+	return sourceFunc(func(conf *Config) error {
+		overrides := append(envOverrides(environ), flagOverrides(args)...)
+		if err := conf.applyOverrides(overrides); err != nil {
+			return fmt.Errorf("failed to override config: %w", err)
+		}
+		return nil
+	})
+}
+
+// New builds a config from sources, resolving the secrets once all of them are merged.
+// Overlays are selected by app.Env() and ${env:} references read os.Environ(), use Load to set them.
+// The returned config is never modified, it is safe for concurrent use and independent of GetInstance.
+func New(sources ...Source) (*Config, error) {
+	return build(Options{Env: string(app.Env()), Environ: os.Environ()}, sources)
+}
+
+func build(opts Options, sources []Source) (*Config, error) {
+	conf := &Config{
+		configs: make(map[string]map[string]interface{}),
+		origins: make(map[string]string),
+		opts:    opts,
+		sources: sources,
+	}
+	for _, source := range sources {
+		if err := source.load(conf); err != nil {
+			return nil, err
+		}
+	}
+	if opts.KeepSecrets {
+		return conf, nil
+	}
+	if err := conf.resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("failed to resolve secret: %w", err)
+	}
+	return conf, nil
+}
+
+// reload builds a new config from the sources of conf.
+func (conf *Config) reload() (*Config, error) {
+	return build(conf.opts, conf.sources)
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/holgerfy/go-pkg/log"
+)
+
+type subscriber struct {
+	node string
+	key  string
+	fn   func(old, new interface{})
+}
+
+var (
+	subscribers struct {
+		lock sync.Mutex
+		list []subscriber
+	}
+	validators struct {
+		lock sync.Mutex
+		list []func(conf *Config) error
+	}
+)
+
+// the log level follows the level key of the log node, e.g. log.toml: level = "info"
+func init() {
+	OnChange("log", "level", func(old, new interface{}) {
+		if level, ok := new.(string); ok {
+			if err := log.SetLevel(level); err != nil {
+				log.Logger().Error(context.Background(), "invalid log level ", level)
+			}
+		}
+	})
+}
+
+// OnChange calls fn with the old and new value of node.key whenever a load or reload changes it.
+// An empty key watches the whole node, a missing value is nil.
+func OnChange(node, key string, fn func(old, new interface{})) {
+	subscribers.lock.Lock()
+	defer subscribers.lock.Unlock()
+
+	subscribers.list = append(subscribers.list, subscriber{node: node, key: key, fn: fn})
+}
+
+// AddValidator adds a check a reloaded config has to pass before it replaces the current one.
+func AddValidator(fn func(conf *Config) error) {
+	validators.lock.Lock()
+	defer validators.lock.Unlock()
+
+	validators.list = append(validators.list, fn)
+}
+
+func validate(conf *Config) error {
+	validators.lock.Lock()
+	list := validators.list
+	validators.lock.Unlock()
+
+	for _, fn := range list {
+		if err := fn(conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (conf *Config) value(node, key string) interface{} {
+	if conf == nil {
+		return nil
+	}
+	nodeVal, ok := conf.configs[node]
+	if !ok {
+		return nil
+	}
+	if key == "" {
+		return copyValue(nodeVal)
+	}
+	return copyValue(nodeVal[key])
+}
+
+// swap replaces the current config and notifies the subscribers of changed values.
+func swap(conf *Config) {
+	configLock.Lock()
+	old := config
+	config = conf
+	configLock.Unlock()
+
+	subscribers.lock.Lock()
+	list := subscribers.list
+	subscribers.lock.Unlock()
+
+	for _, sub := range list {
+		oldVal, newVal := old.value(sub.node, sub.key), conf.value(sub.node, sub.key)
+		if !reflect.DeepEqual(oldVal, newVal) {
+			sub.fn(oldVal, newVal)
+		}
+	}
+}
+
+// Reload re-reads the sources of the current config. If they don't parse, a registered target
+// doesn't bind (see RegisterTarget) or a validator fails, the current config is kept and the error returned.
+func Reload() error {
+	current := GetInstance()
+	if current == nil {
+		return ErrNotLoaded
+	}
+	conf, err := current.reload()
+	if err != nil {
+		return err
+	}
+	for _, res := range conf.Check() {
+		if errors.Is(res.Err, ErrInvalid) {
+			return fmt.Errorf("%s: %w", res.Path, res.Err)
+		}
+	}
+	if err := validate(conf); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	swap(conf)
+	return nil
+}
+
+// Watch polls the config files every interval and reloads them when one is added, removed or modified,
+// or when a remote provider reports a change.
+// It blocks until ctx is done, so run it in a worker: app.Go("config", func(ctx context.Context) error { return config.Watch(ctx, 5*time.Second) }).
+func Watch(ctx context.Context, interval time.Duration) error {
+	current := GetInstance()
+	if current == nil {
+		return ErrNotLoaded
+	}
+	changed := make(chan struct{}, 1)
+	for _, p := range current.providers {
+		go func(p Provider) {
+			err := p.Watch(ctx, func() {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			})
+			if err != nil {
+				log.Logger().Error(ctx, "failed to watch remote config, err: ", err)
+			}
+		}(p)
+	}
+	last := fingerprint(current)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			fp := fingerprint(GetInstance())
+			if fp == last {
+				continue
+			}
+			last = fp
+		case <-changed:
+		}
+		if err := Reload(); err != nil {
+			log.Logger().Error(ctx, "failed to reload config, keeping the current one, err: ", GetInstance().RedactString(err.Error()))
+			continue
+		}
+		log.Logger().Info(ctx, "config reloaded")
+	}
+}
+
+// fingerprint identifies the state of the config files, the included ones too, by name, size and modification time.
+func fingerprint(conf *Config) string {
+	files, err := listFiles(conf.dirs, conf.opts.Env)
+	if err != nil {
+		return err.Error()
+	}
+	paths := append([]string{}, conf.files...)
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	var fp string
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err == nil {
+			fp += fmt.Sprintf("%s:%d:%d;", path, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return fp
+}
@@ -9,11 +9,15 @@ import (
 	"github.com/holgerfy/go-pkg/app"
 	"github.com/holgerfy/go-pkg/config"
 	"github.com/holgerfy/go-pkg/funcs"
+	"github.com/holgerfy/go-pkg/health"
 	"github.com/holgerfy/go-pkg/log"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"io/ioutil"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -34,13 +38,16 @@ const (
 	NearestMode            = readpref.NearestMode
 )
 
+const saturationThreshold = 0.9 // of max_pool_size in use on the busiest server
+
 var (
 	client *mongo.Client
+	pool   = &poolStats{inUse: make(map[string]int)}
 	conf   struct {
-		URL             string `toml:"url"`
+		URL             string `toml:"url" validate:"required"`
 		DbName          string `toml:"database"`
-		MaxConnIdleTime int    `toml:"max_conn_idle_time"`
-		MaxPoolSize     int    `toml:"max_pool_size"`
+		MaxConnIdleTime int    `toml:"max_conn_idle_time" validate:"min=0"`
+		MaxPoolSize     int    `toml:"max_pool_size" validate:"min=0"`
 		Username        string `toml:"username"`
 		Password        string `toml:"password"`
 		ReplicaSet      string `toml:"replicaSet"`
@@ -49,6 +56,10 @@ var (
 	}
 )
 
+func init() {
+	config.RegisterTarget("db", "mongo", &conf)
+}
+
 type (
 	CollectionInfo struct {
 		Database   *mongo.Database
@@ -61,16 +72,31 @@ type (
 	}
 )
 
-// Start mongo
-func Start() {
+// Start mongo with the db.mongo node of the first config passed, config.GetInstance() by default
+func Start(configs ...*config.Config) {
 	ctx := log.WithFields(context.Background(), map[string]string{"action": "startMongo"})
 	log.Logger().Info(ctx, "test ")
+	cfg := config.GetInstance()
+	if len(configs) > 0 {
+		cfg = configs[0]
+	}
+	if err := start(ctx, cfg); err != nil {
+		log.Logger().Error(ctx, cfg.RedactString(err.Error()))
+	}
+}
+
+func start(ctx context.Context, cfg *config.Config) error {
 	var err error
-	err = config.GetInstance().Bind("db", "mongo", &conf)
+	err = cfg.Bind("db", "mongo", &conf)
 	if err == config.ErrNodeNotExists {
-		return
+		return nil
+	}
+	registerProbes()
+	if err != nil {
+		return err
 	}
 	mongoOptions := options.Client()
+	mongoOptions.SetPoolMonitor(pool.monitor())
 	mongoOptions.SetMaxConnIdleTime(time.Duration(conf.MaxConnIdleTime) * time.Second)
 	mongoOptions.SetMaxPoolSize(uint64(conf.MaxPoolSize))
 	mongoOptions.SetRetryReads(true)
@@ -80,16 +106,15 @@ func Start() {
 	}
 	if conf.IsSsl {
 		certs := x509.NewCertPool()
-		if pemData, err := ioutil.ReadFile(funcs.GetRoot() + conf.CaCert); err != nil {
-			log.Logger().Info(ctx, "failed to read cert, err: ", err)
-			return
+		if pemData, err := ioutil.ReadFile(filepath.Join(app.Root(), conf.CaCert)); err != nil {
+			return fmt.Errorf("failed to read cert, err: %w", err)
 		} else {
 			certs.AppendCertsFromPEM(pemData)
 		}
 		tlsConf := &tls.Config{
 			RootCAs: certs,
 		}
-		if funcs.GetEnv() == app.EnvModelLocal {
+		if !app.CurrentProfile().StrictTLS {
 			tlsConf.InsecureSkipVerify = true
 		}
 		mongoOptions.SetTLSConfig(tlsConf)
@@ -101,14 +126,95 @@ func Start() {
 
 	client, err = mongo.NewClient(mongoOptions.ApplyURI(conf.URL))
 	if err != nil {
-		log.Logger().Info(ctx, "self build new client, err: ", err)
-		return
+		return fmt.Errorf("self build new client, err: %w", err)
 	}
 
-	mgoCtx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	mgoCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 	err = client.Connect(mgoCtx)
 	if err != nil {
-		log.Logger().Error(ctx, "failed to connect, err: ", err)
+		return fmt.Errorf("failed to connect, err: %w", err)
+	}
+	return nil
+}
+
+type poolStats struct {
+	lock  sync.Mutex
+	inUse map[string]int
+}
+
+func (p *poolStats) monitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			switch evt.Type {
+			case event.GetSucceeded:
+				p.inUse[evt.Address]++
+			case event.ConnectionReturned:
+				p.inUse[evt.Address]--
+			case event.PoolClosedEvent:
+				delete(p.inUse, evt.Address)
+			}
+		},
+	}
+}
+
+// saturation is the highest share of connections in use over all server pools.
+func (p *poolStats) saturation() float64 {
+	if conf.MaxPoolSize <= 0 {
+		return 0
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var busiest int
+	for _, n := range p.inUse {
+		if n > busiest {
+			busiest = n
+		}
+	}
+	return float64(busiest) / float64(conf.MaxPoolSize)
+}
+
+func registerProbes() {
+	health.Register(health.Probe{
+		Name:  "mongo",
+		Level: health.Critical,
+		Check: func(ctx context.Context) error {
+			if client == nil {
+				return errors.New("mongo client not started")
+			}
+			return client.Ping(ctx, readpref.Primary())
+		},
+	})
+	health.Register(health.Probe{
+		Name:  "mongo_pool",
+		Level: health.NonCritical,
+		Check: func(ctx context.Context) error {
+			if saturation := pool.saturation(); saturation >= saturationThreshold {
+				return fmt.Errorf("pool saturated: %.0f%% of %d connections in use", saturation*100, conf.MaxPoolSize)
+			}
+			return nil
+		},
+	})
+}
+
+// Stop disconnects the client and closes its pool.
+func Stop(ctx context.Context) error {
+	if client == nil {
+		return nil
+	}
+	return client.Disconnect(ctx)
+}
+
+func Component() *app.Component {
+	return &app.Component{
+		Name:      "mongo",
+		DependsOn: []string{"config", "log"},
+		Start: func(ctx context.Context) error {
+			return start(log.WithFields(ctx, map[string]string{"action": "startMongo"}), config.GetInstance())
+		},
+		Stop: Stop,
 	}
 }
 
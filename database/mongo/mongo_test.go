@@ -1,20 +1,31 @@
 package mongo
 
 import (
+	"context"
 	"fmt"
+	"github.com/holgerfy/go-pkg/app"
 	"github.com/holgerfy/go-pkg/config"
-	"github.com/holgerfy/go-pkg/funcs"
 	"github.com/holgerfy/go-pkg/log"
 	"github.com/holgerfy/go-pkg/redis"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestStart(t *testing.T) {
+	// the logs go to a temp root, the config comes from the fixture dir
+	app.SetRoot(t.TempDir())
 	log.Start()
-	config.LoadConfig([]string{funcs.GetRoot()})
+	config.LoadConfig([]string{filepath.Join("testdata", "config")})
 	Start()
 	redis.Start()
 
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if client == nil || client.Ping(ctx, nil) != nil {
+		t.Skip("mongo not reachable at the url of testdata/config/db.toml")
+	}
+
 	//mongo.Client.Database("tmm").Collection("")
 	num, err := Database("tmm_im").SetTable("user_info").Count()
 	fmt.Println(num, err)
@@ -0,0 +1,163 @@
+package errno
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain of the ErrorInfo status detail that carries an Errno.
+const Domain = "errno"
+
+// grpcCodes has no entry for OK, an error is never sent as codes.OK.
+var grpcCodes = map[int]codes.Code{
+	DefErr:       codes.InvalidArgument,
+	TokenErr:     codes.Unauthenticated,
+	Exception:    codes.FailedPrecondition,
+	403:          codes.PermissionDenied,
+	404:          codes.NotFound,
+	409:          codes.AlreadyExists,
+	WrongReq:     codes.InvalidArgument,
+	429:          codes.ResourceExhausted,
+	SysErr:       codes.Internal,
+	HeaderErr:    codes.InvalidArgument,
+	TimesLimited: codes.ResourceExhausted,
+	503:          codes.Unavailable,
+	504:          codes.DeadlineExceeded,
+}
+
+// SetGrpcCode sets the grpc code a business code is sent with, call it before serving.
+func SetGrpcCode(code int, grpcCode codes.Code) {
+	grpcCodes[code] = grpcCode
+}
+
+// GrpcCode returns the grpc code of a business code, Internal for the unknown system errors
+// and Unknown for the others, OK included: a non-nil error must not arrive as a nil one.
+func GrpcCode(code int, isNetErr uint8) codes.Code {
+	if c, ok := grpcCodes[code]; ok && c != codes.OK {
+		return c
+	}
+	if isNetErr == 1 || code >= 500 {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// ToStatus converts err to a grpc status, an *Errno keeps its code, message and IsNetErr in an ErrorInfo detail.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	var e *Errno
+	if !errors.As(err, &e) {
+		return status.Convert(err)
+	}
+	st := status.New(GrpcCode(e.Code, e.IsNetErr), e.Msg)
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: strconv.Itoa(e.Code),
+		Domain: Domain,
+		Metadata: map[string]string{
+			"code":   strconv.Itoa(e.Code),
+			"msg":    e.Msg,
+			"is_net": strconv.Itoa(int(e.IsNetErr)),
+		},
+	})
+	if detailErr != nil {
+		return st
+	}
+	return detailed
+}
+
+// FromStatus returns the Errno carried by st, false if it doesn't carry one.
+func FromStatus(st *status.Status) (*Errno, bool) {
+	if st == nil {
+		return nil, false
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != Domain {
+			continue
+		}
+		code, err := strconv.Atoi(info.Metadata["code"])
+		if err != nil {
+			continue
+		}
+		isNetErr, _ := strconv.Atoi(info.Metadata["is_net"])
+		return &Errno{Code: code, Msg: info.Metadata["msg"], IsNetErr: uint8(isNetErr)}, true
+	}
+	return nil, false
+}
+
+// FromError turns a grpc error carrying an Errno back into the *Errno, other errors are returned as they are.
+func FromError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	if e, ok := FromStatus(st); ok {
+		return e
+	}
+	return err
+}
+
+// GrpcUnaryServerInterceptor sends the *Errno returned by handlers as statuses. Chain it after
+// log.GrpcUnaryServerInterceptor so the log sees the converted code.
+func GrpcUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, ToStatus(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+func GrpcStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return ToStatus(err).Err()
+		}
+		return nil
+	}
+}
+
+// GrpcUnaryClientInterceptor returns the errors carrying an Errno as *Errno, so errno.Add("x", 422)
+// returned downstream arrives as the same Errno.
+func GrpcUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return FromError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// GrpcStreamClientInterceptor is GrpcUnaryClientInterceptor for streams, the errors of opening,
+// sending and receiving carrying an Errno are returned as *Errno.
+func GrpcStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, FromError(err)
+		}
+		return clientStream{cs}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s clientStream) SendMsg(m interface{}) error {
+	return FromError(s.ClientStream.SendMsg(m))
+}
+
+func (s clientStream) RecvMsg(m interface{}) error {
+	return FromError(s.ClientStream.RecvMsg(m))
+}
+
+func (s clientStream) CloseSend() error {
+	return FromError(s.ClientStream.CloseSend())
+}
@@ -0,0 +1,83 @@
+package errno
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type failingHealth struct {
+	healthpb.UnimplementedHealthServer
+	err error
+}
+
+func (s failingHealth) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return nil, s.err
+}
+
+func (s failingHealth) Watch(*healthpb.HealthCheckRequest, healthpb.Health_WatchServer) error {
+	return s.err
+}
+
+func TestStatus(t *testing.T) {
+	want := &Errno{Code: WrongReq, Msg: "invalid name", IsNetErr: 0}
+	st := ToStatus(want)
+	if st.Code() != codes.InvalidArgument || st.Message() != want.Msg {
+		t.Fatalf("status = %v", st)
+	}
+	got, ok := FromStatus(st)
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("errno = %+v, %v", got, ok)
+	}
+	if _, ok := FromStatus(status.New(codes.NotFound, "missing")); ok {
+		t.Fatal("plain status carries no errno")
+	}
+	if c := ToStatus(AddSysErr("db down", 599)).Code(); c != codes.Internal {
+		t.Fatalf("unknown system error code = %v", c)
+	}
+	if st := ToStatus(Add("done", OK)); st.Code() == codes.OK || st.Err() == nil {
+		t.Fatalf("an error was sent as %v", st.Code())
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(GrpcUnaryServerInterceptor()), grpc.StreamInterceptor(GrpcStreamServerInterceptor()))
+	healthpb.RegisterHealthServer(srv, failingHealth{err: Add("x", WrongReq)})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(GrpcUnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(GrpcStreamClientInterceptor()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	var e *Errno
+	if !errors.As(err, &e) || e.Code != WrongReq || e.Msg != "x" || e.IsNetErr != 0 {
+		t.Fatalf("err = %#v", err)
+	}
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	if !errors.As(err, &e) || e.Code != WrongReq || e.Msg != "x" {
+		t.Fatalf("stream err = %#v", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Package flags evaluates feature flags defined in the flags config node, e.g. flags.toml:
+//
+//	[new_checkout]
+//	enabled = true
+//	percentage = 20              # share of the users, hashed on the user ID, default 100
+//	users = ["1001", "1002"]     # always on for them
+//	envs = ["dev", "release"]    # only in these RUN_ENV, default all
+//	min_version = "2.3.0"        # only from this app version on
+//
+// Rules overridden at runtime through a Store replace the configured ones on every instance.
+package flags
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/holgerfy/go-pkg/app"
+	"github.com/holgerfy/go-pkg/config"
+	"github.com/holgerfy/go-pkg/funcs"
+	"github.com/holgerfy/go-pkg/log"
+	"go.uber.org/zap"
+)
+
+const node = "flags"
+
+type Rule struct {
+	Enabled    bool     `toml:"enabled"`
+	Percentage *int     `toml:"percentage" validate:"min=0,max=100"` // nil means 100, for the config and the overrides alike
+	Users      []string `toml:"users"`
+	Envs       []string `toml:"envs"`
+	MinVersion string   `toml:"min_version"`
+}
+
+type ctxKey int
+
+const (
+	userKey ctxKey = iota
+	versionKey
+	resultsKey
+)
+
+var (
+	state struct {
+		lock      sync.RWMutex
+		rules     map[string]Rule
+		overrides map[string]Rule
+	}
+	ErrNoStore = errors.New("flags store not available")
+)
+
+func init() {
+	config.RegisterTarget(node, "", &map[string]Rule{})
+	config.OnChange(node, "", func(old, new interface{}) {
+		if err := loadRules(config.GetInstance()); err != nil {
+			log.Logger().Error(context.Background(), "failed to load flags, keeping the current ones, err: ", config.GetInstance().RedactString(err.Error()))
+		}
+	})
+}
+
+// WithUser sets the user the flags are evaluated for.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey, userID)
+}
+
+// WithVersion sets the app version min_version is compared with, the client's for instance.
+// Without it the version of the binary is used, see app.BuildInfo.
+func WithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, versionKey, version)
+}
+
+// Enabled tells if the flag name is on for the user and version of ctx. Unknown flags are off.
+// Flags evaluated by With keep their result for ctx.
+func Enabled(ctx context.Context, name string) bool {
+	if results, ok := ctx.Value(resultsKey).(map[string]bool); ok {
+		if on, ok := results[name]; ok {
+			return on
+		}
+	}
+	rule, ok := lookup(name)
+	if !ok {
+		return false
+	}
+	return rule.evaluate(ctx, name)
+}
+
+// With evaluates the flags names once for ctx and adds the results to its log fields as flag.<name>.
+func With(ctx context.Context, names ...string) context.Context {
+	results := make(map[string]bool)
+	if prev, ok := ctx.Value(resultsKey).(map[string]bool); ok {
+		for name, on := range prev {
+			results[name] = on
+		}
+	}
+	fields := make([]zap.Field, 0, len(names))
+	for _, name := range names {
+		on := Enabled(ctx, name)
+		results[name] = on
+		fields = append(fields, zap.Bool("flag."+name, on))
+	}
+	return log.NewContext(context.WithValue(ctx, resultsKey, results), fields...)
+}
+
+func lookup(name string) (Rule, bool) {
+	state.lock.RLock()
+	defer state.lock.RUnlock()
+
+	if rule, ok := state.overrides[name]; ok {
+		return rule, true
+	}
+	rule, ok := state.rules[name]
+	return rule, ok
+}
+
+func (r Rule) evaluate(ctx context.Context, name string) bool {
+	if !r.Enabled {
+		return false
+	}
+	if len(r.Envs) > 0 && !contains(r.Envs, string(app.Env())) {
+		return false
+	}
+	if r.MinVersion != "" && funcs.CompareVersion(version(ctx), strings.TrimPrefix(r.MinVersion, "v")) < 0 {
+		return false
+	}
+	userID, _ := ctx.Value(userKey).(string)
+	if userID != "" && contains(r.Users, userID) {
+		return true
+	}
+	percentage := 100
+	if r.Percentage != nil {
+		percentage = *r.Percentage
+	}
+	if percentage >= 100 {
+		return true
+	}
+	if userID == "" || percentage <= 0 {
+		return false
+	}
+	return int(funcs.Crc32(name+":"+userID)%100) < percentage
+}
+
+func version(ctx context.Context) string {
+	v, ok := ctx.Value(versionKey).(string)
+	if !ok {
+		v = app.BuildInfo().Version
+	}
+	return strings.TrimPrefix(v, "v")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// loadRules binds the flags node of conf, a missing node means no flags.
+func loadRules(conf *config.Config) error {
+	rules := make(map[string]Rule)
+	if err := conf.Bind(node, "", &rules); err != nil && !errors.Is(err, config.ErrNodeNotExists) && !errors.Is(err, config.ErrNotLoaded) {
+		return err
+	}
+	state.lock.Lock()
+	state.rules = rules
+	state.lock.Unlock()
+	return nil
+}
+
+func setOverrides(overrides map[string]Rule) {
+	state.lock.Lock()
+	state.overrides = overrides
+	state.lock.Unlock()
+}
+
+// Rules returns the rules in effect, the overridden ones included.
+func Rules() map[string]Rule {
+	state.lock.RLock()
+	defer state.lock.RUnlock()
+
+	res := make(map[string]Rule, len(state.rules)+len(state.overrides))
+	for name, rule := range state.rules {
+		res[name] = rule
+	}
+	for name, rule := range state.overrides {
+		res[name] = rule
+	}
+	return res
+}
@@ -0,0 +1,148 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/holgerfy/go-pkg/config"
+)
+
+type memoryStore struct {
+	lock     sync.Mutex
+	rules    map[string]Rule
+	watchers []chan struct{}
+}
+
+func (s *memoryStore) Load(ctx context.Context) (map[string]Rule, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res := make(map[string]Rule, len(s.rules))
+	for name, rule := range s.rules {
+		res[name] = rule
+	}
+	return res, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, name string, rule *Rule) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if rule == nil {
+		delete(s.rules, name)
+	} else {
+		s.rules[name] = *rule
+	}
+	for _, ch := range s.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Watch(ctx context.Context, changed func()) error {
+	ch := make(chan struct{}, 1)
+	s.lock.Lock()
+	s.watchers = append(s.watchers, ch)
+	s.lock.Unlock()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ch:
+			changed()
+		}
+	}
+}
+
+func setRules(t *testing.T, rules map[string]interface{}) {
+	conf, err := config.New(config.Map("flags", rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadRules(conf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	setRules(t, map[string]interface{}{
+		"off":      map[string]interface{}{"enabled": false},
+		"on":       map[string]interface{}{"enabled": true},
+		"dev_only": map[string]interface{}{"enabled": true, "envs": []interface{}{"dev"}},
+		"v2":       map[string]interface{}{"enabled": true, "min_version": "2.0.0"},
+		"half":     map[string]interface{}{"enabled": true, "percentage": int64(50), "users": []interface{}{"vip"}},
+	})
+	ctx := context.Background()
+	user := WithUser(ctx, "42")
+	cases := []struct {
+		ctx  context.Context
+		name string
+		want bool
+	}{
+		{ctx, "missing", false},
+		{ctx, "off", false},
+		{ctx, "on", true},
+		{ctx, "dev_only", false},
+		{WithVersion(ctx, "1.9.9"), "v2", false},
+		{WithVersion(ctx, "v2.1.0"), "v2", true},
+		{ctx, "half", false},
+		{WithUser(ctx, "vip"), "half", true},
+	}
+	for _, c := range cases {
+		if got := Enabled(c.ctx, c.name); got != c.want {
+			t.Errorf("Enabled(%s) = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	on := 0
+	for i := 0; i < 1000; i++ {
+		if Enabled(WithUser(ctx, fmt.Sprint(i)), "half") {
+			on++
+		}
+	}
+	if on < 400 || on > 600 {
+		t.Errorf("half is on for %d of 1000 users", on)
+	}
+
+	ctx = With(user, "on", "off")
+	setRules(t, map[string]interface{}{"on": map[string]interface{}{"enabled": false}})
+	if !Enabled(ctx, "on") || Enabled(user, "on") {
+		t.Error("With should keep the results for its context only")
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	setRules(t, map[string]interface{}{"checkout": map[string]interface{}{"enabled": false}})
+	store := &memoryStore{rules: make(map[string]Rule)}
+	SetStore(store)
+	defer SetStore(RedisStore{})
+	defer setOverrides(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Watch(ctx, time.Hour)
+
+	if err := SetOverride(ctx, "checkout", Rule{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	// without a percentage the override is on for everyone
+	if !Enabled(ctx, "checkout") || !Enabled(WithUser(ctx, "42"), "checkout") {
+		t.Fatal("override not applied")
+	}
+
+	// another instance clears it, this one learns it from the watch, once it's subscribed
+	deadline := time.Now().Add(2 * time.Second)
+	for Enabled(ctx, "checkout") {
+		if time.Now().After(deadline) {
+			t.Fatal("override still applied after it was cleared")
+		}
+		store.Save(ctx, "checkout", nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+}
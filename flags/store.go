@@ -0,0 +1,203 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/holgerfy/go-pkg/app"
+	"github.com/holgerfy/go-pkg/config"
+	"github.com/holgerfy/go-pkg/log"
+	"github.com/holgerfy/go-pkg/redis"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Store keeps the rules overridden at runtime, shared by all the instances.
+type Store interface {
+	// Load returns the overridden rules by flag name.
+	Load(ctx context.Context) (map[string]Rule, error)
+	// Save overrides the rule of name, or removes the override if rule is nil, and notifies the watchers.
+	Save(ctx context.Context, name string, rule *Rule) error
+	// Watch blocks until ctx is done and calls changed whenever an override may have changed.
+	Watch(ctx context.Context, changed func()) error
+}
+
+// RedisStore keeps the overrides as JSON in the hash HashKey of redis.Client and publishes
+// the changes on Channel.
+type RedisStore struct{}
+
+const (
+	HashKey = "flags"
+	Channel = "flags:changed"
+)
+
+var (
+	store Store = RedisStore{}
+	json        = jsoniter.Config{EscapeHTML: true, TagKey: "toml"}.Froze()
+)
+
+// SetStore replaces the store of the overrides, RedisStore by default.
+func SetStore(s Store) {
+	state.lock.Lock()
+	store = s
+	state.lock.Unlock()
+}
+
+func getStore() Store {
+	state.lock.RLock()
+	defer state.lock.RUnlock()
+
+	return store
+}
+
+func (RedisStore) Load(ctx context.Context) (map[string]Rule, error) {
+	if redis.Client == nil {
+		return nil, ErrNoStore
+	}
+	values, err := redis.Client.WithContext(ctx).HGetAll(HashKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]Rule, len(values))
+	for name, value := range values {
+		var rule Rule
+		if err := json.UnmarshalFromString(value, &rule); err != nil {
+			log.Logger().Error(ctx, "invalid flag override ", name, ", err: ", err)
+			continue
+		}
+		res[name] = rule
+	}
+	return res, nil
+}
+
+func (RedisStore) Save(ctx context.Context, name string, rule *Rule) error {
+	if redis.Client == nil {
+		return ErrNoStore
+	}
+	client := redis.Client.WithContext(ctx)
+	if rule == nil {
+		if err := client.HDel(HashKey, name).Err(); err != nil {
+			return err
+		}
+	} else {
+		value, err := json.MarshalToString(rule)
+		if err != nil {
+			return err
+		}
+		if err := client.HSet(HashKey, name, value).Err(); err != nil {
+			return err
+		}
+	}
+	return client.Publish(Channel, name).Err()
+}
+
+func (RedisStore) Watch(ctx context.Context, changed func()) error {
+	if redis.Client == nil {
+		return ErrNoStore
+	}
+	pubsub := redis.Client.Subscribe(Channel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(); err != nil {
+		return err
+	}
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			changed()
+		}
+	}
+}
+
+// SetOverride replaces the rule of name on every instance until ClearOverride.
+func SetOverride(ctx context.Context, name string, rule Rule) error {
+	if err := getStore().Save(ctx, name, &rule); err != nil {
+		return err
+	}
+	return loadOverrides(ctx)
+}
+
+func ClearOverride(ctx context.Context, name string) error {
+	if err := getStore().Save(ctx, name, nil); err != nil {
+		return err
+	}
+	return loadOverrides(ctx)
+}
+
+func loadOverrides(ctx context.Context) error {
+	overrides, err := getStore().Load(ctx)
+	if err != nil {
+		return err
+	}
+	setOverrides(overrides)
+	return nil
+}
+
+// Start loads the flags node of the current config and the overrides. Without a store the
+// configured rules apply alone.
+func Start() error {
+	if err := loadRules(config.GetInstance()); err != nil {
+		return err
+	}
+	if err := loadOverrides(context.Background()); err != nil {
+		log.Logger().Warn(context.Background(), "failed to load flag overrides, err: ", err)
+	}
+	return nil
+}
+
+// Watch reloads the overrides whenever the store reports a change, and every interval in case
+// a notification was lost. It blocks until ctx is done, so run it in a worker:
+// app.Go("flags", func(ctx context.Context) error { return flags.Watch(ctx, time.Minute) }).
+func Watch(ctx context.Context, interval time.Duration) error {
+	changed := make(chan struct{}, 1)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- getStore().Watch(ctx, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watchErr:
+			if errors.Is(err, ErrNoStore) {
+				return nil
+			}
+			if err != nil {
+				// the worker restarts Watch with backoff
+				return err
+			}
+			// the store stopped notifying, keep polling
+			watchErr = nil
+		case <-changed:
+		case <-ticker.C:
+		}
+		if err := loadOverrides(ctx); err != nil {
+			log.Logger().Error(ctx, "failed to reload flag overrides, err: ", err)
+		}
+	}
+}
+
+// Component starts flags after config. It doesn't depend on redis, the store may be another one
+// or the service may have none: append it after the redis component for the first load to see
+// the overrides, Watch loads them otherwise.
+func Component() *app.Component {
+	return &app.Component{
+		Name:      "flags",
+		DependsOn: []string{"config"},
+		Start: func(ctx context.Context) error {
+			return Start()
+		},
+	}
+}
@@ -296,6 +296,7 @@ func RemoveDuplicatesAndEmpty(arr []string) (ret []string) {
 	return
 }
 
+// Deprecated: GetRoot is the binary dir, which is a temp dir under go run and go test. Use app.Root.
 func GetRoot() string {
 	dir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
 	return strings.Replace(dir, "\\", "/", -1)
@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const watchInterval = 5 * time.Second
+
+// GrpcServer implements grpc.health.v1. The empty service name reports readiness of the
+// whole process, any other name is looked up as a probe.
+type GrpcServer struct {
+	healthpb.UnimplementedHealthServer
+	registry *Registry
+}
+
+func (r *Registry) GrpcServer() *GrpcServer {
+	return &GrpcServer{registry: r}
+}
+
+// RegisterGRPC serves the default registry as grpc.health.v1 on s.
+func RegisterGRPC(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, registry.GrpcServer())
+}
+
+func (s *GrpcServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, ok := s.status(ctx, req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+func (s *GrpcServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		st, ok := s.status(stream.Context(), req.GetService())
+		if !ok {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *GrpcServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	if service == "" {
+		if s.registry.Check(ctx, Critical).Status == StatusDown {
+			return healthpb.HealthCheckResponse_NOT_SERVING, true
+		}
+		return healthpb.HealthCheckResponse_SERVING, true
+	}
+	probe, ok := s.registry.get(service)
+	if !ok {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	if run(ctx, probe).Status != StatusUp {
+		return healthpb.HealthCheckResponse_NOT_SERVING, true
+	}
+	return healthpb.HealthCheckResponse_SERVING, true
+}
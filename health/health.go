@@ -0,0 +1,217 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Level uint8
+
+const (
+	NonCritical Level = iota // reported, never fails a probe endpoint
+	Critical                 // failure fails /readyz
+	Fatal                    // failure fails /healthz and /readyz
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+
+	DefaultTimeout = 3 * time.Second
+)
+
+var (
+	registry   = NewRegistry()
+	ErrTimeout = errors.New("probe timed out")
+)
+
+type Probe struct {
+	Name    string
+	Check   func(ctx context.Context) error
+	Timeout time.Duration
+	Level   Level
+}
+
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Level    string `json:"level"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+	level    Level
+}
+
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type Registry struct {
+	lock   sync.RWMutex
+	probes map[string]Probe
+}
+
+func (l Level) String() string {
+	switch l {
+	case Critical:
+		return "critical"
+	case Fatal:
+		return "fatal"
+	}
+	return "non-critical"
+}
+
+func NewRegistry() *Registry {
+	return &Registry{probes: make(map[string]Probe)}
+}
+
+func Default() *Registry {
+	return registry
+}
+
+// Register adds a probe to the default registry, a probe with the same name is replaced.
+func Register(probe Probe) {
+	registry.Register(probe)
+}
+
+func Unregister(name string) {
+	registry.Unregister(name)
+}
+
+func (r *Registry) Register(probe Probe) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.probes[probe.Name] = probe
+}
+
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.probes, name)
+}
+
+func (r *Registry) get(name string) (Probe, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	probe, ok := r.probes[name]
+	return probe, ok
+}
+
+func (r *Registry) list() []Probe {
+	r.lock.RLock()
+	probes := make([]Probe, 0, len(r.probes))
+	for _, probe := range r.probes {
+		probes = append(probes, probe)
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(probes, func(i, j int) bool {
+		return probes[i].Name < probes[j].Name
+	})
+	return probes
+}
+
+// Check runs all probes concurrently. A report is down if a probe at or above minLevel fails,
+// and degraded if only less critical probes fail.
+func (r *Registry) Check(ctx context.Context, minLevel Level) Report {
+	probes := r.list()
+	results := make([]Result, len(probes))
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func(i int, probe Probe) {
+			defer wg.Done()
+			results[i] = run(ctx, probe)
+		}(i, probe)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, res := range results {
+		if res.Status == StatusUp {
+			continue
+		}
+		if res.level >= minLevel {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, probe Probe) Result {
+	timeout := probe.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				done <- fmt.Errorf("probe panic: %v", err)
+			}
+		}()
+		done <- probe.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimeout
+	}
+	res := Result{
+		Name:     probe.Name,
+		Status:   StatusUp,
+		Level:    probe.Level.String(),
+		Duration: time.Since(startTime).String(),
+		level:    probe.Level,
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// LiveHandler serves /healthz, only Fatal probes fail it.
+func (r *Registry) LiveHandler() http.Handler {
+	return r.handler(Fatal)
+}
+
+// ReadyHandler serves /readyz, Critical and Fatal probes fail it.
+func (r *Registry) ReadyHandler() http.Handler {
+	return r.handler(Critical)
+}
+
+func (r *Registry) handler(minLevel Level) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Check(req.Context(), minLevel)
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status == StatusDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// RegisterHTTP mounts /healthz and /readyz of the default registry on mux.
+func RegisterHTTP(mux *http.ServeMux) {
+	mux.Handle("/healthz", registry.LiveHandler())
+	mux.Handle("/readyz", registry.ReadyHandler())
+}
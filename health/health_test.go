@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistryCheck(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Probe{Name: "ping", Level: Critical, Check: func(ctx context.Context) error { return nil }})
+	r.Register(Probe{Name: "pool", Level: NonCritical, Check: func(ctx context.Context) error { return errors.New("saturated") }})
+	if report := r.Check(context.Background(), Critical); report.Status != StatusDegraded {
+		t.Fatalf("expected degraded, got %s", report.Status)
+	}
+
+	r.Register(Probe{Name: "ping", Level: Critical, Timeout: 10 * time.Millisecond, Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}})
+	report := r.Check(context.Background(), Critical)
+	if report.Status != StatusDown || report.Checks[0].Error != ErrTimeout.Error() {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz returned %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	r.LiveHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("healthz returned %d", rec.Code)
+	}
+}
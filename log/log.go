@@ -2,8 +2,9 @@ package log
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/holgerfy/go-pkg/funcs"
+	"github.com/holgerfy/go-pkg/app"
 	"github.com/holgerfy/go-pkg/unique"
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
 	"go.uber.org/zap"
@@ -12,40 +13,97 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"io"
+	"net/http"
 	"os"
 	"runtime"
 	"strconv"
+	"syscall"
 	"time"
 )
 
 type Log struct {
 	logger *zap.Logger
+	level  zap.AtomicLevel
 }
 
 const loggerKey = iota
 
-var log = &Log{}
+// the no-op logger keeps calls made before Start from panicking
+var log = &Log{logger: zap.NewNop(), level: zap.NewAtomicLevelAt(zap.DebugLevel)}
 
 func Start() {
-	level := zap.DebugLevel
+	profile := app.CurrentProfile()
+	if err := log.level.UnmarshalText([]byte(profile.LogLevel)); err != nil {
+		log.level.SetLevel(zap.DebugLevel)
+	}
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	encoderConfig.MessageKey = "msg"
 	encoderConfig.TimeKey = "ts"
 	encoderConfig.LevelKey = "level"
-	encoder := zapcore.NewConsoleEncoder(encoderConfig)
+	var encoder zapcore.Encoder
+	if profile.LogEncoder == "json" {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
 
 	core := zapcore.NewCore(
 		encoder,
 		zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout), zapcore.AddSync(getWriter())),
-		level,
+		log.level,
 	)
-	log.logger = zap.New(core, zap.AddStacktrace(zap.ErrorLevel))
+	var opts []zap.Option
+	if profile.StackTraces {
+		opts = append(opts, zap.AddStacktrace(zap.ErrorLevel))
+	}
+	log.logger = zap.New(core, opts...)
+	app.SetLogger(log)
+}
+
+// SetLevel changes the level of the running logger, e.g. "info".
+func SetLevel(level string) error {
+	return log.level.UnmarshalText([]byte(level))
+}
+
+func Level() string {
+	return log.level.String()
+}
+
+// LevelHandler reports the level on GET and changes it on PUT with a body like {"level":"info"}.
+func LevelHandler() http.Handler {
+	return log.level
+}
+
+// Stop flushes buffered log entries.
+func Stop() error {
+	if log.logger == nil {
+		return nil
+	}
+	err := log.logger.Sync()
+	// stdout can't be synced when it is a terminal or a pipe
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		return nil
+	}
+	return err
+}
+
+func Component() *app.Component {
+	return &app.Component{
+		Name: "log",
+		Start: func(ctx context.Context) error {
+			Start()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return Stop()
+		},
+	}
 }
 
 func getWriter() io.Writer {
-	logDir := funcs.GetRoot()
+	logDir := app.Root()
 	logWriter, _ := rotatelogs.New(logDir+"/%Y%m%d.log",
 		rotatelogs.WithMaxAge(time.Hour*24*7),
 		rotatelogs.WithRotationTime(time.Hour*24),
@@ -0,0 +1,129 @@
+// Package ratelimit limits named operations with the token buckets of the ratelimit config node,
+// e.g. ratelimit.toml:
+//
+//	[sms]
+//	rate = 5       # tokens added per second
+//	burst = 10     # bucket size, default the rate rounded up
+//
+// The limits follow the config when it's reloaded, see config.Watch.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/holgerfy/go-pkg/app"
+	"github.com/holgerfy/go-pkg/config"
+	"github.com/holgerfy/go-pkg/log"
+)
+
+const node = "ratelimit"
+
+type Rule struct {
+	Rate  float64 `toml:"rate" validate:"min=0"`
+	Burst int     `toml:"burst" validate:"min=0"`
+}
+
+type bucket struct {
+	rule   Rule
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+var state struct {
+	lock    sync.RWMutex
+	buckets map[string]*bucket
+}
+
+func init() {
+	config.RegisterTarget(node, "", &map[string]Rule{})
+	config.OnChange(node, "", func(old, new interface{}) {
+		if err := load(config.GetInstance()); err != nil {
+			log.Logger().Error(context.Background(), "failed to load rate limits, keeping the current ones, err: ", err)
+		}
+	})
+}
+
+// Allow takes a token of name, false once its limit is reached. Names without a rule aren't limited.
+func Allow(name string) bool {
+	state.lock.RLock()
+	b, ok := state.buckets[name]
+	state.lock.RUnlock()
+	if !ok {
+		return true
+	}
+	return b.allow(time.Now())
+}
+
+func (r Rule) burst() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return math.Max(math.Ceil(r.Rate), 1)
+}
+
+func (b *bucket) allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.tokens = math.Min(b.tokens+now.Sub(b.last).Seconds()*b.rule.Rate, b.rule.burst())
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Rules returns the limits in effect.
+func Rules() map[string]Rule {
+	state.lock.RLock()
+	defer state.lock.RUnlock()
+
+	res := make(map[string]Rule, len(state.buckets))
+	for name, b := range state.buckets {
+		res[name] = b.rule
+	}
+	return res
+}
+
+// load binds the ratelimit node of conf, a missing node means no limits. The buckets whose rule
+// didn't change keep their tokens.
+func load(conf *config.Config) error {
+	rules := make(map[string]Rule)
+	if err := conf.Bind(node, "", &rules); err != nil && !errors.Is(err, config.ErrNodeNotExists) && !errors.Is(err, config.ErrNotLoaded) {
+		return err
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	buckets := make(map[string]*bucket, len(rules))
+	for name, rule := range rules {
+		if b, ok := state.buckets[name]; ok && b.rule == rule {
+			buckets[name] = b
+			continue
+		}
+		buckets[name] = &bucket{rule: rule, tokens: rule.burst(), last: time.Now()}
+	}
+	state.buckets = buckets
+	return nil
+}
+
+// Start loads the ratelimit node of the current config.
+func Start() error {
+	return load(config.GetInstance())
+}
+
+func Component() *app.Component {
+	return &app.Component{
+		Name:      "ratelimit",
+		DependsOn: []string{"config"},
+		Start: func(ctx context.Context) error {
+			return Start()
+		},
+	}
+}
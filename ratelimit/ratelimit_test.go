@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/holgerfy/go-pkg/config"
+)
+
+func setRules(t *testing.T, rules map[string]interface{}) {
+	conf, err := config.New(config.Map("ratelimit", rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := load(conf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllow(t *testing.T) {
+	state.buckets = nil
+	setRules(t, map[string]interface{}{"sms": map[string]interface{}{"rate": 1.0, "burst": int64(2)}})
+	if !Allow("sms") || !Allow("sms") || Allow("sms") {
+		t.Fatal("expected a burst of 2")
+	}
+	if !Allow("unlimited") {
+		t.Fatal("names without a rule aren't limited")
+	}
+
+	// an unchanged rule keeps its bucket, a changed one starts full
+	setRules(t, map[string]interface{}{
+		"sms":   map[string]interface{}{"rate": 1.0, "burst": int64(2)},
+		"email": map[string]interface{}{"rate": 0.0, "burst": int64(1)},
+	})
+	if Allow("sms") {
+		t.Fatal("reloading reset an unchanged bucket")
+	}
+	if !Allow("email") || Allow("email") {
+		t.Fatal("expected a single email")
+	}
+
+	b := state.buckets["sms"]
+	if !b.allow(time.Now().Add(time.Second)) {
+		t.Fatal("a token should be added every second")
+	}
+}
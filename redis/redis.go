@@ -1,29 +1,58 @@
 package redis
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"github.com/go-redis/redis"
+	"github.com/holgerfy/go-pkg/app"
 	"github.com/holgerfy/go-pkg/config"
+	"github.com/holgerfy/go-pkg/health"
+	"github.com/holgerfy/go-pkg/log"
 	"time"
 )
 
+// share of pool_size busy at which the pool probe fails
+const saturationThreshold = 0.9
+
 var (
 	Client *redis.Client
 	conf   struct {
-		Addr         string `toml:"addr"`
+		Addr         string `toml:"addr" validate:"required"`
 		Password     string `toml:"password"`
 		Db           int    `toml:"dao"`
-		PoolSize     int    `toml:"pool_size"`
-		MinIdleConns int    `toml:"min_idle_conns"`
-		IsEnableTls  int    `toml:"is_enable_tls"`
+		PoolSize     int    `toml:"pool_size" validate:"min=0"`
+		MinIdleConns int    `toml:"min_idle_conns" validate:"min=0"`
+		IsEnableTls  int    `toml:"is_enable_tls" validate:"oneof=0 1"`
 	}
 	NilErr = redis.Nil
 )
 
-func Start() {
-	err := config.GetInstance().Bind("db", "redis", &conf)
+func init() {
+	config.RegisterTarget("db", "redis", &conf)
+}
+
+// Start redis with the db.redis node of the first config passed, config.GetInstance() by default
+func Start(configs ...*config.Config) {
+	cfg := config.GetInstance()
+	if len(configs) > 0 {
+		cfg = configs[0]
+	}
+	if err := start(cfg); err != nil {
+		log.Logger().Error(context.Background(), "failed to start redis, err: ", cfg.RedactString(err.Error()))
+	}
+}
+
+func start(cfg *config.Config) error {
+	err := cfg.Bind("db", "redis", &conf)
 	if err == config.ErrNodeNotExists {
-		return
+		return nil
+	}
+	// an invalid db.redis has to show in readiness too
+	registerProbes()
+	if err != nil {
+		return err
 	}
 	opt := &redis.Options{
 		Addr:         conf.Addr,
@@ -37,11 +66,62 @@ func Start() {
 	}
 	if conf.IsEnableTls == 1 {
 		opt.TLSConfig = &tls.Config{
-			InsecureSkipVerify: true,
+			InsecureSkipVerify: !app.CurrentProfile().StrictTLS,
 			MinVersion:         tls.VersionTLS12,
 		}
 	}
 	Client = redis.NewClient(opt)
+	return nil
+}
+
+func registerProbes() {
+	health.Register(health.Probe{
+		Name:  "redis",
+		Level: health.Critical,
+		Check: func(ctx context.Context) error {
+			if Client == nil {
+				return errors.New("redis client not started")
+			}
+			return Client.WithContext(ctx).Ping().Err()
+		},
+	})
+	health.Register(health.Probe{
+		Name:  "redis_pool",
+		Level: health.NonCritical,
+		Check: func(ctx context.Context) error {
+			if Client == nil {
+				return nil
+			}
+			poolSize := Client.Options().PoolSize
+			stats := Client.PoolStats()
+			inUse := int(stats.TotalConns - stats.IdleConns)
+			if float64(inUse) >= float64(poolSize)*saturationThreshold {
+				return fmt.Errorf("pool saturated: %d of %d connections in use, %d timeouts", inUse, poolSize, stats.Timeouts)
+			}
+			return nil
+		},
+	})
+}
+
+// Stop closes the client and its pool.
+func Stop() error {
+	if Client == nil {
+		return nil
+	}
+	return Client.Close()
+}
+
+func Component() *app.Component {
+	return &app.Component{
+		Name:      "redis",
+		DependsOn: []string{"config", "log"},
+		Start: func(ctx context.Context) error {
+			return start(config.GetInstance())
+		},
+		Stop: func(ctx context.Context) error {
+			return Stop()
+		},
+	}
 }
 
 func CacheGet(key string, expiration time.Duration, f func() string) string {
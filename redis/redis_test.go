@@ -0,0 +1,23 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/holgerfy/go-pkg/config"
+	"github.com/holgerfy/go-pkg/health"
+)
+
+func TestStartInvalidConfig(t *testing.T) {
+	conf, err := config.New(config.Map("db", map[string]interface{}{"redis": map[string]interface{}{"addr": ""}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := start(conf); !errors.Is(err, config.ErrInvalid) {
+		t.Fatalf("start = %v, want a validation error", err)
+	}
+	if report := health.Default().Check(context.Background(), health.Critical); report.Status != health.StatusDown {
+		t.Fatalf("readiness = %+v, want down", report)
+	}
+}
@@ -3,18 +3,35 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/holgerfy/go-pkg/app"
 	"github.com/holgerfy/go-pkg/config"
-	"github.com/holgerfy/go-pkg/funcs"
+	"github.com/holgerfy/go-pkg/health"
 	"github.com/holgerfy/go-pkg/log"
 	"github.com/holgerfy/go-pkg/unique"
 	"net/http"
 )
 
 func main() {
-	config.LoadConfig([]string{funcs.GetRoot() + "/test-go-pkg/"})
-	log.Start()
+	server := &http.Server{Addr: ":8888"}
 	http.HandleFunc("/test", test)
-	err := http.ListenAndServe(":8888", nil)
+	http.Handle("/version", app.VersionHandler())
+	health.RegisterHTTP(http.DefaultServeMux)
+	err := app.Run(
+		log.Component(),
+		config.Component([]string{app.Root() + "/test-go-pkg/"}),
+		&app.Component{
+			Name: "http",
+			Start: func(ctx context.Context) error {
+				go func() {
+					if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+						log.Logger().Error(ctx, err)
+					}
+				}()
+				return nil
+			},
+			Stop: server.Shutdown,
+		},
+	)
 	if err != nil {
 		fmt.Println(err)
 	}